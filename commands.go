@@ -1,20 +1,302 @@
 package main
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
 	"gator/internal/config"
 	"gator/internal/database"
+	"gator/internal/render"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Exit codes, following the usual convention of 2 for bad invocations.
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
 )
 
 type state struct {
-	db     *database.Queries
+	db *database.Queries
+	// conn is the connection pool behind db, used to start transactions
+	conn   *sql.DB
 	Config config.Config
+	// output is the format picked with the global --output flag
+	output render.Format
+}
+
+// newOutput returns a writer for a listing command's rows in the format
+// picked by the user. fields name the columns, see the README.
+func (s *state) newOutput(fields ...string) *render.Writer {
+	return render.NewWriter(os.Stdout, s.output, fields...)
 }
 
+// command is an invocation after parsing. Args holds the positional
+// arguments, Flags the flags that were given, keyed by name without dashes.
+// Boolean flags are stored as "true".
 type command struct {
+	Name  string
+	Args  []string
+	Flags map[string]string
+}
+
+// Flag returns the value of a flag and whether it was given.
+func (c command) Flag(name string) (string, bool) {
+	value, ok := c.Flags[name]
+	return value, ok
+}
+
+// Arg returns the positional argument at i, or "" when an optional argument
+// was left out.
+func (c command) Arg(i int) string {
+	if i < len(c.Args) {
+		return c.Args[i]
+	}
+	return ""
+}
+
+// completer lists the values a shell may offer for an argument or flag.
+type completer func(s *state) ([]string, error)
+
+type arg struct {
+	Name     string
+	Optional bool
+	// Variadic collects the remaining arguments, it must come last
+	Variadic bool
+	Complete completer
+}
+
+type flag struct {
 	Name string
-	Args []string
+	// Value names the flag's value in usage, empty for boolean flags
+	Value    string
+	Usage    string
+	Complete completer
+}
+
+// commandSpec declares a command. The registry uses it to parse and check
+// arguments before the handler runs, and to print help.
+type commandSpec struct {
+	Name    string
+	Summary string
+	// Details is printed below the usage line by '<cmd> --help'
+	Details string
+	Args    []arg
+	Flags   []flag
+	Handler func(*state, command) error
+	// Hidden commands are left out of help and completion
+	Hidden bool
+	// RawArgs commands get their arguments unparsed and unchecked
+	RawArgs bool
+}
+
+// Usage returns the one line synopsis of the command.
+func (c *commandSpec) Usage() string {
+	parts := []string{"gator", c.Name}
+	for _, f := range c.Flags {
+		if f.Value == "" {
+			parts = append(parts, fmt.Sprintf("[--%s]", f.Name))
+		} else {
+			parts = append(parts, fmt.Sprintf("[--%s %s]", f.Name, f.Value))
+		}
+	}
+	for _, a := range c.Args {
+		name := "<" + a.Name + ">"
+		if a.Variadic {
+			name += "..."
+		}
+		if a.Optional {
+			name = "[" + name + "]"
+		}
+		parts = append(parts, name)
+	}
+	return strings.Join(parts, " ")
+}
+
+func (c *commandSpec) printHelp(w io.Writer) {
+	fmt.Fprintf(w, "%s\n\nUsage: %s\n", c.Summary, c.Usage())
+	if c.Details != "" {
+		fmt.Fprintf(w, "\n%s\n", c.Details)
+	}
+	if len(c.Flags) > 0 {
+		fmt.Fprintln(w, "\nFlags:")
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, f := range c.Flags {
+			name := "--" + f.Name
+			if f.Value != "" {
+				name += " " + f.Value
+			}
+			fmt.Fprintf(tw, "  %s\t%s\n", name, f.Usage)
+		}
+		tw.Flush()
+	}
+}
+
+func (c *commandSpec) flag(name string) (flag, bool) {
+	for _, f := range c.Flags {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return flag{}, false
+}
+
+// parse splits raw arguments into flags and positional arguments and checks
+// them against the spec. "--" ends the flags.
+func (c *commandSpec) parse(raw []string) (command, error) {
+	cmd := command{Name: c.Name, Flags: make(map[string]string)}
+	for i := 0; i < len(raw); i++ {
+		token := raw[i]
+		if token == "--" {
+			cmd.Args = append(cmd.Args, raw[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(token, "--") {
+			cmd.Args = append(cmd.Args, token)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(token, "--"), "=")
+		f, ok := c.flag(name)
+		if !ok {
+			return cmd, usageErrorf("unknown flag --%s", name)
+		}
+		switch {
+		case f.Value == "" && hasValue:
+			return cmd, usageErrorf("flag --%s takes no value", name)
+		case f.Value == "":
+			value = "true"
+		case !hasValue:
+			if i+1 >= len(raw) {
+				return cmd, usageErrorf("flag --%s needs a value", name)
+			}
+			i++
+			value = raw[i]
+		}
+		cmd.Flags[name] = value
+	}
+
+	variadic := false
+	for i, a := range c.Args {
+		if i >= len(cmd.Args) && !a.Optional {
+			return cmd, usageErrorf("missing <%s>", a.Name)
+		}
+		variadic = variadic || a.Variadic
+	}
+	if !variadic && len(cmd.Args) > len(c.Args) {
+		return cmd, usageErrorf("unexpected argument '%s'", cmd.Args[len(c.Args)])
+	}
+	return cmd, nil
+}
+
+// usageError reports arguments a command can't make sense of. It is printed
+// along with the command's usage and exits with exitUsage.
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string { return e.msg }
+
+func usageErrorf(format string, a ...any) error {
+	return &usageError{msg: fmt.Sprintf(format, a...)}
 }
 
 type Commands struct {
-	commands map[string]func(*state, command) error
+	commands map[string]*commandSpec
+}
+
+func (c *Commands) register(spec commandSpec) {
+	c.commands[spec.Name] = &spec
+}
+
+// run parses args, the command line without the program name, runs the
+// command and returns the process exit code. Errors are printed to stderr.
+func (c *Commands) run(s *state, args []string) int {
+	if len(args) == 0 {
+		c.printHelp(os.Stderr)
+		return exitUsage
+	}
+
+	name := args[0]
+	if name == "--help" || name == "-h" {
+		name = "help"
+	}
+	spec, ok := c.commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command '%s', run 'gator help' for a list\n", args[0])
+		return exitUsage
+	}
+	var cmd command
+	var err error
+	if spec.RawArgs {
+		cmd = command{Name: spec.Name, Args: args[1:]}
+	} else {
+		for _, a := range args[1:] {
+			if a == "--" {
+				break
+			}
+			if a == "--help" || a == "-h" {
+				spec.printHelp(os.Stdout)
+				return exitOK
+			}
+		}
+		cmd, err = spec.parse(args[1:])
+	}
+	if err == nil {
+		err = spec.Handler(s, cmd)
+	}
+
+	var usageErr *usageError
+	switch {
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(os.Stderr, "%s: %s\nUsage: %s\n", spec.Name, usageErr.msg, spec.Usage())
+		return exitUsage
+	case err != nil:
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	return exitOK
+}
+
+func (c *Commands) printHelp(w io.Writer) {
+	names := c.names()
+	fmt.Fprintln(w, "Usage: gator [--output table|json|ndjson|csv] <command> [arguments]")
+	fmt.Fprintln(w, "\nCommands:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(tw, "  %s\t%s\n", name, c.commands[name].Summary)
+	}
+	tw.Flush()
+	fmt.Fprintln(w, "\nRun 'gator help <command>' for details on a command.")
+}
+
+// names returns the names of the visible commands, sorted.
+func (c *Commands) names() []string {
+	names := make([]string, 0, len(c.commands))
+	for name, spec := range c.commands {
+		if !spec.Hidden {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// handleHelp lists the commands, or describes the one named.
+func (c *Commands) handleHelp(s *state, cmd command) error {
+	if len(cmd.Args) == 0 {
+		c.printHelp(os.Stdout)
+		return nil
+	}
+	spec, ok := c.commands[cmd.Args[0]]
+	if !ok {
+		return usageErrorf("unknown command '%s'", cmd.Args[0])
+	}
+	spec.printHelp(os.Stdout)
+	return nil
 }
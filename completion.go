@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"gator/internal/render"
+	"strings"
+)
+
+// completeCommand is the hidden command the completion scripts call back
+// into. Its arguments are the words of the command line after "gator", the
+// last one being the word under the cursor, possibly empty. It prints the
+// candidates one per line, printing nothing lets the shell fall back to file
+// names.
+const completeCommand = "__complete"
+
+var completionScripts = map[string]string{
+	"bash": `# bash completion for gator, load with: source <(gator completion bash)
+_gator() {
+    local line=${COMP_LINE:0:COMP_POINT}
+    local -a words
+    read -ra words <<< "$line"
+    [[ $line == *[[:space:]] ]] && words+=("")
+    local IFS=$'\n'
+    COMPREPLY=($(gator __complete "${words[@]:1}" 2>/dev/null))
+    # bash splits words at characters such as : and =, only the part of a
+    # candidate after the start of the word bash is completing is inserted
+    local word=${words[${#words[@]}-1]} cur=${COMP_WORDS[COMP_CWORD]}
+    local prefix=${word%"$cur"}
+    if [[ -n $prefix ]]; then
+        COMPREPLY=("${COMPREPLY[@]#"$prefix"}")
+    fi
+}
+complete -o default -F _gator gator
+`,
+	"zsh": `# zsh completion for gator, load with: source <(gator completion zsh)
+_gator() {
+    local -a candidates
+    candidates=(${(f)"$(gator __complete "${(@)words[2,CURRENT]}" 2>/dev/null)"})
+    if (( ${#candidates} )); then
+        compadd -- "${candidates[@]}"
+    else
+        _files
+    fi
+}
+compdef _gator gator
+`,
+	"fish": `# fish completion for gator, load with: gator completion fish | source
+function __gator_complete
+    set -l words (commandline -opc)
+    set -l current (commandline -ct)
+    set -l candidates (gator __complete $words[2..-1] "$current" 2>/dev/null)
+    if test (count $candidates) -eq 0
+        __fish_complete_path "$current"
+        return
+    end
+    printf '%s\n' $candidates
+end
+complete -c gator -f -a '(__gator_complete)'
+`,
+}
+
+func handleCompletion(s *state, cmd command) error {
+	script, ok := completionScripts[cmd.Args[0]]
+	if !ok {
+		return usageErrorf("unsupported shell '%s', expected bash, zsh or fish", cmd.Args[0])
+	}
+	fmt.Print(script)
+	return nil
+}
+
+func (c *Commands) handleComplete(s *state, cmd command) error {
+	words := cmd.Args
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current := words[len(words)-1]
+	for _, candidate := range c.complete(s, words[:len(words)-1], current) {
+		if strings.HasPrefix(candidate, current) {
+			fmt.Println(candidate)
+		}
+	}
+	return nil
+}
+
+// complete returns the candidates for current given the words before it.
+// Errors, such as an unreachable database, simply leave no candidates.
+func (c *Commands) complete(s *state, previous []string, current string) []string {
+	if n := len(previous); n > 0 && (previous[n-1] == "--output" || previous[n-1] == "-o") {
+		return []string{string(render.Table), string(render.JSON), string(render.NDJSON), string(render.CSV)}
+	}
+	// the global flag may appear anywhere, leave it out of the walk below
+	var words []string
+	for i := 0; i < len(previous); i++ {
+		switch w := previous[i]; {
+		case w == "--output" || w == "-o":
+			i++
+		case !strings.HasPrefix(w, "--output="):
+			words = append(words, w)
+		}
+	}
+
+	if len(words) == 0 {
+		if strings.HasPrefix(current, "-") {
+			return []string{"--output"}
+		}
+		return c.names()
+	}
+	spec, ok := c.commands[words[0]]
+	if !ok || spec.Hidden {
+		return nil
+	}
+
+	var pending *flag
+	var positional int
+	flagsEnded := false
+	for _, w := range words[1:] {
+		switch {
+		case pending != nil:
+			pending = nil
+		case flagsEnded || !strings.HasPrefix(w, "--"):
+			positional++
+		case w == "--":
+			flagsEnded = true
+		default:
+			name, _, hasValue := strings.Cut(strings.TrimPrefix(w, "--"), "=")
+			if f, ok := spec.flag(name); ok && f.Value != "" && !hasValue {
+				pending = &f
+			}
+		}
+	}
+	if pending != nil {
+		return completions(s, pending.Complete)
+	}
+
+	if !flagsEnded && strings.HasPrefix(current, "-") {
+		// --flag=value is completed as a whole
+		if name, _, ok := strings.Cut(strings.TrimPrefix(current, "--"), "="); ok {
+			f, _ := spec.flag(name)
+			var candidates []string
+			for _, value := range completions(s, f.Complete) {
+				candidates = append(candidates, "--"+name+"="+value)
+			}
+			return candidates
+		}
+
+		candidates := []string{"--help"}
+		for _, f := range spec.Flags {
+			candidates = append(candidates, "--"+f.Name)
+		}
+		return candidates
+	}
+
+	if len(spec.Args) == 0 {
+		return nil
+	}
+	if positional >= len(spec.Args) {
+		last := spec.Args[len(spec.Args)-1]
+		if !last.Variadic {
+			return nil
+		}
+		return completions(s, last.Complete)
+	}
+	return completions(s, spec.Args[positional].Complete)
+}
+
+func completions(s *state, complete completer) []string {
+	if complete == nil {
+		return nil
+	}
+	candidates, err := complete(s)
+	if err != nil {
+		return nil
+	}
+	return candidates
+}
+
+func completeWords(words ...string) completer {
+	return func(s *state) ([]string, error) {
+		return words, nil
+	}
+}
+
+func (c *Commands) completeCommands(s *state) ([]string, error) {
+	return c.names(), nil
+}
+
+func completeUsers(s *state) ([]string, error) {
+	users, err := s.db.GetUsers(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(users))
+	for _, user := range users {
+		names = append(names, user.Name)
+	}
+	return names, nil
+}
+
+func completeFeeds(s *state) ([]string, error) {
+	feeds, err := s.db.GetFeeds(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	urls := make([]string, 0, len(feeds))
+	for _, feed := range feeds {
+		urls = append(urls, feed.FeedUrl)
+	}
+	return urls, nil
+}
+
+// completeFollowedFeeds lists the feeds the current user follows.
+func completeFollowedFeeds(s *state) ([]string, error) {
+	follows, err := s.db.GetFeedFollowsForUser(context.Background(), s.Config.CurrentUsername)
+	if err != nil {
+		return nil, err
+	}
+	urls := make([]string, 0, len(follows))
+	for _, f := range follows {
+		urls = append(urls, f.FeedUrl)
+	}
+	return urls, nil
+}
+
+func completeCategories(s *state) ([]string, error) {
+	return s.db.GetCategories(context.Background())
+}
@@ -4,4 +4,14 @@ go 1.25.4
 
 require github.com/google/uuid v1.6.0
 
-require github.com/lib/pq v1.10.9 // indirect
+require github.com/lib/pq v1.10.9
+
+require (
+	golang.org/x/net v0.57.0
+	golang.org/x/term v0.45.0
+)
+
+require (
+	golang.org/x/sys v0.47.0 // indirect
+	golang.org/x/text v0.40.0 // indirect
+)
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"gator/internal/database"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultAggWorkers = 4
+	defaultAggPerHost = 1
+	// aggIdleWait is how long a worker sleeps when no feed is due.
+	aggIdleWait = 10 * time.Second
+	// aggHostBusyWait is how long a feed is put back for when its host
+	// already has perHost fetches running.
+	aggHostBusyWait = 5 * time.Second
+)
+
+func handleAgg(s *state, cmd command) error {
+	interval, err := time.ParseDuration(cmd.Args[0])
+	if err != nil {
+		return usageErrorf("invalid duration: %v", err)
+	}
+	if interval < time.Second*10 {
+		return usageErrorf("invalid duration, should be at least 10s")
+	}
+
+	workers := defaultAggWorkers
+	if len(cmd.Args) > 1 {
+		workers, err = strconv.Atoi(cmd.Args[1])
+		if err != nil || workers < 1 {
+			return usageErrorf("invalid worker count '%s'", cmd.Args[1])
+		}
+	}
+
+	perHost := defaultAggPerHost
+	if len(cmd.Args) > 2 {
+		perHost, err = strconv.Atoi(cmd.Args[2])
+		if err != nil || perHost < 1 {
+			return usageErrorf("invalid per-host limit '%s'", cmd.Args[2])
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Printf("Collecting feeds every %s with %d workers\n", interval, workers)
+
+	limiter := newHostLimiter(perHost)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			aggWorker(ctx, s, interval, limiter)
+		}()
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// aggWorker claims due feeds and scrapes them until ctx is cancelled,
+// sleeping for aggIdleWait whenever nothing is due. interval is the default
+// cadence for feeds without a schedule of their own. Feeds on a busy host are
+// put back rather than waited for, so they don't hold up other hosts.
+func aggWorker(ctx context.Context, s *state, interval time.Duration, limiter *hostLimiter) {
+	// the claim must outlast the fetch or another worker takes the feed too
+	claim := max(interval, 2*feedFetchTimeout)
+	for ctx.Err() == nil {
+		feed, err := s.db.GetNextFeedToFetch(ctx, claim.Seconds())
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) && ctx.Err() == nil {
+				fmt.Printf("agg: failed to claim feed: %v\n", err)
+			}
+			select {
+			case <-ctx.Done():
+			case <-time.After(aggIdleWait):
+			}
+			continue
+		}
+
+		host := feed.Url
+		if u, err := url.Parse(feed.Url); err == nil {
+			host = u.Hostname()
+		}
+
+		release, ok := limiter.tryAcquire(host)
+		if !ok {
+			err := s.db.DeferFeed(ctx, database.DeferFeedParams{
+				ID:           feed.ID,
+				DelaySeconds: aggHostBusyWait.Seconds(),
+			})
+			if err != nil && ctx.Err() == nil {
+				fmt.Printf("agg: '%s': failed to defer: %v\n", feed.Name, err)
+			}
+			continue
+		}
+		err = scrapeFeed(ctx, s, feed, interval)
+		release()
+		if err != nil {
+			fmt.Printf("agg: '%s': %v\n", feed.Name, err)
+		}
+	}
+}
+
+// hostLimiter bounds the number of concurrent fetches against a single host.
+type hostLimiter struct {
+	mu    sync.Mutex
+	limit int
+	slots map[string]chan struct{}
+}
+
+func newHostLimiter(limit int) *hostLimiter {
+	return &hostLimiter{
+		limit: limit,
+		slots: make(map[string]chan struct{}),
+	}
+}
+
+// tryAcquire takes a slot for host if one is free, returning the function
+// that gives it back.
+func (l *hostLimiter) tryAcquire(host string) (func(), bool) {
+	l.mu.Lock()
+	slot, ok := l.slots[host]
+	if !ok {
+		slot = make(chan struct{}, l.limit)
+		l.slots[host] = slot
+	}
+	l.mu.Unlock()
+
+	select {
+	case slot <- struct{}{}:
+		return func() { <-slot }, true
+	default:
+		return nil, false
+	}
+}
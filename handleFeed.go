@@ -3,74 +3,115 @@ package main
 import (
 	"context"
 	"database/sql"
-	"encoding/xml"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"gator/internal/database"
+	"gator/internal/discovery"
+	"gator/internal/pubdate"
+	"gator/internal/redirect"
 	"gator/internal/rss"
+	"gator/internal/schedule"
 	"html"
 	"net/http"
+	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-func fetchFeed(ctx context.Context, feedUrl string) (*rss.RSSFeed, error) {
+// feedFetchTimeout bounds a whole fetch, body included, so a server that
+// never answers can't hold on to a worker.
+const feedFetchTimeout = 30 * time.Second
+
+// feedResponse is the outcome of a conditional fetch. When notModified is set
+// the server answered 304 and feed is nil.
+type feedResponse struct {
+	feed          *rss.RSSFeed
+	status        int
+	notModified   bool
+	etag          string
+	lastModified  string
+	cacheLifetime time.Duration
+	// movedTo is the final URL when every redirect on the way was permanent
+	movedTo string
+}
+
+// fetchError is returned by fetchFeed once a response was received, carrying
+// its HTTP status for feed health tracking.
+type fetchError struct {
+	status int
+	err    error
+}
+
+func (e *fetchError) Error() string { return e.err.Error() }
+func (e *fetchError) Unwrap() error { return e.err }
+
+func fetchFeed(ctx context.Context, feedUrl, etag, lastModified string) (*feedResponse, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedUrl, nil)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("User-Agent", "gator")
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
 
-	res, err := http.DefaultClient.Do(req)
+	client, redirects := redirect.Track(&http.Client{Timeout: feedFetchTimeout})
+	res, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer res.Body.Close()
 
-	var result rss.RSSFeed
-	dec := xml.NewDecoder(res.Body)
-	err = dec.Decode(&result)
-	if err != nil {
-		return nil, err
+	result := &feedResponse{
+		movedTo:       redirects.MovedTo(),
+		status:        res.StatusCode,
+		etag:          res.Header.Get("ETag"),
+		lastModified:  res.Header.Get("Last-Modified"),
+		cacheLifetime: schedule.CacheLifetime(res.Header, time.Now()),
 	}
-
-	result.Channel.Title = html.UnescapeString(result.Channel.Title)
-	result.Channel.Description = html.UnescapeString(result.Channel.Description)
-
-	return &result, nil
-}
-
-func handleAgg(s *state, cmd command) error {
-	if len(cmd.Args) != 1 {
-		return fmt.Errorf("agg: expected duration")
+	if res.StatusCode == http.StatusNotModified {
+		// a 304 may omit validators that are still current
+		if result.etag == "" {
+			result.etag = etag
+		}
+		if result.lastModified == "" {
+			result.lastModified = lastModified
+		}
+		result.notModified = true
+		return result, nil
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, &fetchError{status: res.StatusCode, err: fmt.Errorf("unexpected status: %s", res.Status)}
 	}
 
-	interval, err := time.ParseDuration(cmd.Args[0])
+	result.feed, err = rss.Parse(res.Body, res.Header.Get("Content-Type"))
 	if err != nil {
-		return fmt.Errorf("agg: invalid duration: %w", err)
-	}
-	if interval < time.Second*10 {
-		return errors.New("agg: invalid duration, should be at least 10s")
+		return nil, &fetchError{status: res.StatusCode, err: err}
 	}
 
-	ticker := time.NewTicker(interval)
-	for {
-		scrapeFeeds(s)
-		<-ticker.C
-	}
+	result.feed.Channel.Title = html.UnescapeString(result.feed.Channel.Title)
+	result.feed.Channel.Description = html.UnescapeString(result.feed.Channel.Description)
+
+	return result, nil
 }
 
 func handleAddFeed(s *state, cmd command, user database.User) error {
-	if len(cmd.Args) != 2 {
-		return fmt.Errorf("addfeed: invalid arguments, expected %d but got %d", 2, len(cmd.Args))
-	}
-
 	ctx := context.Background()
 
 	name := cmd.Args[0]
-	url := cmd.Args[1]
+	url, err := discoverFeedURL(ctx, cmd.Args[1])
+	if err != nil {
+		return fmt.Errorf("addfeed: %w", err)
+	}
+
 	feed, err := s.db.CreateFeed(ctx, database.CreateFeedParams{
 		ID:        uuid.New(),
 		CreatedAt: time.Now(),
@@ -96,7 +137,163 @@ func handleAddFeed(s *state, cmd command, user database.User) error {
 	return nil
 }
 
+// moveFeed points feed at newUrl after a permanent redirect, keeping the old
+// URL as an alias. If another feed already uses newUrl the two are merged into
+// it, follows and posts included, and that feed is returned.
+func moveFeed(ctx context.Context, s *state, feed database.Feed, newUrl string) (database.Feed, error) {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return feed, err
+	}
+	defer tx.Rollback()
+	qtx := s.db.WithTx(tx)
+
+	target, err := qtx.GetFeed(ctx, newUrl)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return feed, err
+	}
+	if err == nil && target.ID != feed.ID {
+		err = mergeFeed(ctx, qtx, feed, target)
+	} else {
+		target = feed
+		target.Url = newUrl
+		err = qtx.UpdateFeedURL(ctx, database.UpdateFeedURLParams{ID: feed.ID, Url: newUrl})
+	}
+	if err != nil {
+		return feed, err
+	}
+
+	err = qtx.CreateFeedURLAlias(ctx, database.CreateFeedURLAliasParams{
+		Url:       feed.Url,
+		FeedID:    target.ID,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return feed, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return feed, err
+	}
+
+	if target.ID == feed.ID {
+		fmt.Printf("Feed '%s' moved permanently: %s -> %s\n", feed.Name, feed.Url, newUrl)
+	} else {
+		fmt.Printf("Feed '%s' moved permanently: %s -> %s, merged into '%s'\n", feed.Name, feed.Url, newUrl, target.Name)
+	}
+	return target, nil
+}
+
+func mergeFeed(ctx context.Context, qtx *database.Queries, from, to database.Feed) error {
+	err := qtx.MoveFeedFollows(ctx, database.MoveFeedFollowsParams{FromFeedID: from.ID, ToFeedID: to.ID})
+	if err != nil {
+		return err
+	}
+	err = qtx.MovePosts(ctx, database.MovePostsParams{FromFeedID: from.ID, ToFeedID: to.ID})
+	if err != nil {
+		return err
+	}
+	err = qtx.MoveFeedURLAliases(ctx, database.MoveFeedURLAliasesParams{FromFeedID: from.ID, ToFeedID: to.ID})
+	if err != nil {
+		return err
+	}
+	// remaining duplicate follows and posts go with the feed
+	return qtx.DeleteFeed(ctx, from.ID)
+}
+
+// recordFeedFailure backs off a feed whose fetch failed, disabling it once it
+// has failed too many times in a row. It returns the fetch error.
+func recordFeedFailure(ctx context.Context, s *state, feed database.Feed, interval time.Duration, fetchErr error) error {
+	var status sql.NullInt32
+	var fe *fetchError
+	if errors.As(fetchErr, &fe) {
+		status = sql.NullInt32{Int32: int32(fe.status), Valid: true}
+	}
+
+	delay := schedule.Backoff(interval, int(feed.ConsecutiveFailures)+1)
+	updated, err := s.db.RecordFeedFailure(ctx, database.RecordFeedFailureParams{
+		ID:           feed.ID,
+		LastError:    sql.NullString{String: fetchErr.Error(), Valid: true},
+		LastStatus:   status,
+		DelaySeconds: delay.Seconds(),
+		MaxFailures:  int32(s.Config.FeedFailureLimit()),
+	})
+	if err != nil {
+		return fmt.Errorf("scrapefeed: %w", err)
+	}
+
+	if updated.DisabledAt.Valid {
+		return fmt.Errorf("scrapefeed: %w (disabled after %d failures)", fetchErr, updated.ConsecutiveFailures)
+	}
+	return fmt.Errorf("scrapefeed: %w (failure %d, retrying in %s)", fetchErr, updated.ConsecutiveFailures, delay)
+}
+
+func handleInterval(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+
+	feed, err := s.db.GetFeed(ctx, cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("interval: feed not found: %w", err)
+	}
+	if feed.UserID != user.ID {
+		return fmt.Errorf("interval: only the user who added '%s' can change its interval", feed.Name)
+	}
+
+	// without a duration the feed goes back to the publisher's schedule
+	var interval time.Duration
+	if len(cmd.Args) == 2 {
+		interval, err = time.ParseDuration(cmd.Args[1])
+		if err != nil {
+			return usageErrorf("invalid duration: %v", err)
+		}
+		if interval < schedule.MinInterval {
+			return usageErrorf("invalid duration, should be at least %s", schedule.MinInterval)
+		}
+	}
+
+	err = s.db.SetFeedFetchInterval(ctx, database.SetFeedFetchIntervalParams{
+		ID:                   feed.ID,
+		FetchIntervalSeconds: sql.NullInt32{Int32: int32(interval.Seconds()), Valid: interval > 0},
+	})
+	if err != nil {
+		return fmt.Errorf("interval: %w", err)
+	}
+
+	if interval > 0 {
+		fmt.Printf("'%s' will be fetched every %s\n", feed.Name, interval)
+	} else {
+		fmt.Printf("'%s' will be fetched on the publisher's schedule\n", feed.Name)
+	}
+	return nil
+}
+
+// discoverFeedURL resolves a page URL to the single feed it advertises. When a
+// page offers several feeds they are listed and an error is returned so the
+// user can pick one.
+func discoverFeedURL(ctx context.Context, pageUrl string) (string, error) {
+	candidates, err := discovery.Discover(ctx, &http.Client{Timeout: feedFetchTimeout}, pageUrl)
+	if err != nil {
+		return "", fmt.Errorf("no feed found at %s: %w", pageUrl, err)
+	}
+	if len(candidates) == 1 {
+		if candidates[0].URL != pageUrl {
+			fmt.Printf("Found feed %s\n", candidates[0].URL)
+		}
+		return candidates[0].URL, nil
+	}
+
+	fmt.Printf("%s offers several feeds:\n", pageUrl)
+	for _, c := range candidates {
+		fmt.Printf("  %s\t%s\n", c.URL, c.Title)
+	}
+	return "", errors.New("multiple feeds found, run again with one of the URLs above")
+}
+
 func handleListFeeds(s *state, cmd command) error {
+	if _, ok := cmd.Flag("health"); ok {
+		return listFeedsHealth(s)
+	}
+
 	ctx := context.Background()
 
 	feeds, err := s.db.GetFeeds(ctx)
@@ -104,96 +301,411 @@ func handleListFeeds(s *state, cmd command) error {
 		return fmt.Errorf("feeds: %w", err)
 	}
 
+	out := s.newOutput("name", "url", "user")
 	for _, feed := range feeds {
-		fmt.Printf("Name:\t%s\nURL:\t%s\nUser:\t%s\n", feed.FeedName, feed.FeedUrl, feed.UserName)
-		fmt.Println("---")
+		if err := out.Row(feed.FeedName, feed.FeedUrl, feed.UserName); err != nil {
+			return fmt.Errorf("feeds: %w", err)
+		}
 	}
-	return nil
+	return out.Flush()
 }
 
-func scrapeFeeds(s *state) error {
+func listFeedsHealth(s *state) error {
+	feeds, err := s.db.GetFeedsHealth(context.Background())
+	if err != nil {
+		return fmt.Errorf("feeds: %w", err)
+	}
+
+	out := s.newOutput("name", "url", "health", "failures", "last_status", "last_success_at", "last_error", "next_fetch_at", "disabled_at")
+	for _, feed := range feeds {
+		health := "ok"
+		switch {
+		case feed.DisabledAt.Valid:
+			health = "disabled"
+		case feed.ConsecutiveFailures > 0:
+			health = "failing"
+		case !feed.LastFetchedAt.Valid:
+			health = "never fetched"
+		}
+
+		nextFetch := feed.NextFetchAt
+		if feed.DisabledAt.Valid {
+			nextFetch.Valid = false
+		}
+		err := out.Row(feed.Name, feed.Url, health, feed.ConsecutiveFailures, feed.LastStatus,
+			feed.LastSuccessAt, feed.LastError, nextFetch, feed.DisabledAt)
+		if err != nil {
+			return fmt.Errorf("feeds: %w", err)
+		}
+	}
+	return out.Flush()
+}
+
+func handleEnableFeed(s *state, cmd command) error {
 	ctx := context.Background()
-	feed, err := s.db.GetNextFeedToFetch(ctx)
+
+	feed, err := s.db.GetFeed(ctx, cmd.Args[0])
 	if err != nil {
-		return fmt.Errorf("scrapefeed: %w", err)
+		return fmt.Errorf("enablefeed: feed not found: %w", err)
 	}
 
-	err = s.db.MarkFeedFetched(ctx, feed.ID)
+	err = s.db.EnableFeed(ctx, feed.ID)
 	if err != nil {
-		return fmt.Errorf("scrapefeed: %w", err)
+		return fmt.Errorf("enablefeed: %w", err)
 	}
 
-	rssFeed, err := fetchFeed(ctx, feed.Url)
+	fmt.Printf("'%s' enabled, it will be fetched on the next agg cycle\n", feed.Name)
+	return nil
+}
+
+// scrapeFeed fetches a claimed feed, stores its items and schedules the next
+// fetch. interval is the cadence used when neither the user nor the publisher
+// set one.
+func scrapeFeed(ctx context.Context, s *state, feed database.Feed, interval time.Duration) error {
+	res, err := fetchFeed(ctx, feed.Url, feed.Etag.String, feed.LastModified.String)
 	if err != nil {
-		return fmt.Errorf("scrapefeed: %w", err)
+		return recordFeedFailure(ctx, s, feed, interval, err)
 	}
 
-	fmt.Printf("Fetched feed '%s'\n---\n", feed.Name)
-	for _, item := range rssFeed.Channel.Item {
-		pubDate, err := parseDate(item.PubDate)
+	if res.movedTo != "" && res.movedTo != feed.Url {
+		feed, err = moveFeed(ctx, s, feed, res.movedTo)
 		if err != nil {
-			fmt.Println(err)
-		} else {
-			fmt.Printf("Title: %s\nURL: %s\n***\n", pubDate.UTC(), item.Link)
-		}
-
-		_, err = s.db.CreatePost(ctx, database.CreatePostParams{
-			ID:          uuid.New(),
-			CreatedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
-			PublishedAt: pubDate,
-			Title:       item.Title,
-			Url:         item.Link,
-			Description: sql.NullString{String: item.Description, Valid: true},
-			FeedID:      feed.ID,
+			return fmt.Errorf("scrapefeed: failed to move feed: %w", err)
+		}
+	}
+
+	hints := schedule.Hints{
+		Interval:      time.Duration(feed.FetchIntervalSeconds.Int32) * time.Second,
+		Refresh:       time.Duration(feed.TtlSeconds.Int32) * time.Second,
+		CacheLifetime: res.cacheLifetime,
+		SkipHours:     feed.SkipHours,
+		SkipDays:      feed.SkipDays,
+	}
+	if !res.notModified {
+		hints.Refresh = res.feed.RefreshInterval()
+		hints.SkipHours = res.feed.SkipHours()
+		hints.SkipDays = res.feed.SkipDays()
+	}
+
+	delay := schedule.Delay(time.Now(), interval, hints)
+	err = s.db.ScheduleFeed(ctx, database.ScheduleFeedParams{
+		ID:           feed.ID,
+		LastStatus:   sql.NullInt32{Int32: int32(res.status), Valid: true},
+		TtlSeconds:   sql.NullInt32{Int32: int32(hints.Refresh.Seconds()), Valid: hints.Refresh > 0},
+		SkipHours:    append([]int32{}, hints.SkipHours...),
+		SkipDays:     append([]int32{}, hints.SkipDays...),
+		DelaySeconds: delay.Seconds(),
+	})
+	if err != nil {
+		return fmt.Errorf("scrapefeed: %w", err)
+	}
+
+	if res.notModified {
+		if err := saveCacheValidators(ctx, s, feed.ID, res); err != nil {
+			return fmt.Errorf("scrapefeed: %w", err)
+		}
+		fmt.Printf("Feed '%s' not modified, next fetch in %s\n", feed.Name, delay.Round(time.Second))
+		return nil
+	}
+
+	fetchedAt := time.Now().UTC()
+	var inserted, updated, unchanged, failed, undated int
+	for _, item := range res.feed.Channel.Item {
+		pubDate, source := pubdate.ParseOr(item.PubDate, fetchedAt)
+		if source != pubdate.SourceFeed {
+			undated++
+		}
+
+		err := s.db.AdoptLegacyPost(ctx, database.AdoptLegacyPostParams{
+			Guid:   item.Identity(),
+			FeedID: feed.ID,
+			Url:    item.Link,
 		})
 		if err != nil {
-			fmt.Println(err)
+			failed++
+			fmt.Printf("'%s': failed to save post: %v\n", item.Title, err)
+			continue
+		}
+
+		post, err := s.db.CreatePost(ctx, database.CreatePostParams{
+			ID:                uuid.New(),
+			CreatedAt:         time.Now().UTC(),
+			UpdatedAt:         time.Now().UTC(),
+			PublishedAt:       pubDate,
+			PublishedAtSource: source.String(),
+			Title:             item.Title,
+			Url:               item.Link,
+			Description:       sql.NullString{String: item.Description, Valid: true},
+			FeedID:            feed.ID,
+			Guid:              item.Identity(),
+			ContentHash:       item.ContentHash(),
+			SummaryHash:       item.SummaryHash(),
+			Content:           sql.NullString{String: item.Content, Valid: item.Content != ""},
+			Author:            sql.NullString{String: item.Author, Valid: item.Author != ""},
+			RevisionID:        uuid.New(),
+		})
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			// the upsert skips rows whose content hash is unchanged
+			unchanged++
+		case err != nil:
+			failed++
+			fmt.Printf("'%s': failed to save post: %v\n", item.Title, err)
+		case post.Inserted:
+			inserted++
+		default:
+			updated++
+		}
+		// categories aren't part of the content hash, so they're synced for
+		// unchanged posts too
+		if err == nil || errors.Is(err, sql.ErrNoRows) {
+			if err := syncPostCategories(ctx, s, feed.ID, item.Identity(), item.Categories); err != nil {
+				fmt.Printf("'%s': failed to save categories: %v\n", item.Title, err)
+			}
+		}
+	}
+
+	// with the validators saved the next fetch may get a 304, so keep the old
+	// ones until every item is stored
+	if failed == 0 {
+		if err := saveCacheValidators(ctx, s, feed.ID, res); err != nil {
+			return fmt.Errorf("scrapefeed: %w", err)
 		}
 	}
 
-	s.db.CreatePost(ctx, database.CreatePostParams{})
-	fmt.Println("---")
+	fmt.Printf("Fetched feed '%s': %d new, %d updated, %d unchanged", feed.Name, inserted, updated, unchanged)
+	if failed > 0 {
+		fmt.Printf(", %d failed", failed)
+	}
+	if undated > 0 {
+		fmt.Printf(", %d dated by fetch time", undated)
+	}
+	fmt.Printf(", next fetch in %s\n", delay.Round(time.Second))
 	return nil
 }
 
-const dateLayout = "Mon, 02 Jan 2006 15:04:05 -0700"
+func saveCacheValidators(ctx context.Context, s *state, feedID uuid.UUID, res *feedResponse) error {
+	return s.db.SetFeedCacheValidators(ctx, database.SetFeedCacheValidatorsParams{
+		ID:           feedID,
+		Etag:         sql.NullString{String: res.etag, Valid: res.etag != ""},
+		LastModified: sql.NullString{String: res.lastModified, Valid: res.lastModified != ""},
+	})
+}
 
-func parseDate(date string) (time.Time, error) {
-	pubDate, err := time.Parse(dateLayout, date)
+// syncPostCategories replaces the categories of the post with the given GUID
+// by the ones its feed item lists now, if they differ.
+func syncPostCategories(ctx context.Context, s *state, feedID uuid.UUID, guid string, categories []string) error {
+	rows, err := s.db.GetPostCategories(ctx, database.GetPostCategoriesParams{
+		FeedID: feedID,
+		Guid:   guid,
+	})
 	if err != nil {
-		return time.Time{}, err
+		return err
+	}
+	if len(rows) == 0 {
+		return errors.New("post not found")
 	}
 
-	return pubDate, nil
-}
+	var current []string
+	for _, row := range rows {
+		if row.Name.Valid {
+			current = append(current, row.Name.String)
+		}
+	}
+	// names are stored lower case
+	var wanted []string
+	for _, name := range categories {
+		wanted = append(wanted, strings.ToLower(name))
+	}
+	slices.Sort(current)
+	slices.Sort(wanted)
+	wanted = slices.Compact(wanted)
+	if slices.Equal(current, wanted) {
+		return nil
+	}
 
-func handleBrowse(s *state, cmd command, user database.User) error {
-	if len(cmd.Args) > 1 {
-		return fmt.Errorf("browse: too many arguments")
+	// the old categories stay if any write fails, the next fetch tries again
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := s.db.WithTx(tx)
+
+	postID := rows[0].ID
+	if err := qtx.DeletePostCategories(ctx, postID); err != nil {
+		return err
 	}
-	limit := 2
-	var err error
-	if len(cmd.Args) == 1 {
-		limit, err = strconv.Atoi(cmd.Args[0])
+	for _, name := range wanted {
+		category, err := qtx.CreateCategory(ctx, database.CreateCategoryParams{
+			ID:   uuid.New(),
+			Name: name,
+		})
+		if err != nil {
+			return err
+		}
+		err = qtx.AddPostCategory(ctx, database.AddPostCategoryParams{
+			PostID:     postID,
+			CategoryID: category.ID,
+		})
 		if err != nil {
-			return fmt.Errorf("browse: invalid limit: %w", err)
+			return err
 		}
 	}
+	return tx.Commit()
+}
 
+func handleBrowse(s *state, cmd command, user database.User) error {
 	ctx := context.Background()
 
-	posts, err := s.db.GetPostsForUser(ctx, database.GetPostsForUserParams{
+	params := database.BrowsePostsForUserParams{
 		UserID: user.ID,
-		Limit:  int32(limit),
-	})
+		SortBy: "published",
+		Limit:  2,
+	}
+	if len(cmd.Args) > 0 {
+		limit, err := strconv.Atoi(cmd.Args[0])
+		if err != nil || limit < 1 {
+			return usageErrorf("invalid limit '%s'", cmd.Args[0])
+		}
+		params.Limit = int32(limit)
+	}
+	_, params.UnreadOnly = cmd.Flag("unread")
+	if url, ok := cmd.Flag("feed"); ok {
+		feed, err := s.db.GetFeed(ctx, url)
+		if err != nil {
+			return fmt.Errorf("browse: feed not found: %w", err)
+		}
+		params.FeedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+	if value, ok := cmd.Flag("category"); ok {
+		params.Category = sql.NullString{String: value, Valid: true}
+	}
+	for _, name := range []string{"since", "until"} {
+		value, ok := cmd.Flag(name)
+		if !ok {
+			continue
+		}
+		t, err := parseSince(value, time.Now())
+		if err != nil {
+			return usageErrorf("%v", err)
+		}
+		if name == "since" {
+			params.Since = sql.NullTime{Time: t, Valid: true}
+		} else {
+			params.Until = sql.NullTime{Time: t, Valid: true}
+		}
+	}
+	_, hasOffset := cmd.Flag("offset")
+	_, hasPage := cmd.Flag("page")
+	if hasOffset && hasPage {
+		return usageErrorf("--offset and --page can't be combined")
+	}
+	if value, ok := cmd.Flag("offset"); ok {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return usageErrorf("invalid offset '%s'", value)
+		}
+		params.Offset = int32(offset)
+	}
+	if value, ok := cmd.Flag("page"); ok {
+		key, id, err := decodeCursor(value)
+		if err != nil {
+			return usageErrorf("invalid page cursor: %v", err)
+		}
+		params.CursorKey = sql.NullTime{Time: key, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+	if value, ok := cmd.Flag("sort"); ok {
+		if value != "published" && value != "fetched" {
+			return usageErrorf("invalid sort '%s', expected published or fetched", value)
+		}
+		params.SortBy = value
+	}
+
+	posts, err := s.db.BrowsePostsForUser(ctx, params)
 	if err != nil {
-		return fmt.Errorf("browse: failed to fetch posts for user")
+		return fmt.Errorf("browse: failed to fetch posts for user: %w", err)
 	}
 
+	out := s.newOutput("id", "title", "url", "feed", "published_at", "updated", "changed")
 	for _, post := range posts {
-		fmt.Printf("Title: %s\nURL: %s\n***\n", post.Title, post.Url)
+		var changed string
+		if post.Revisions > 0 {
+			revision, err := s.db.GetLatestPostRevision(ctx, post.ID)
+			if err != nil {
+				return fmt.Errorf("browse: failed to fetch revision for post: %w", err)
+			}
+			changed = strings.Join(postChanges(revision, post), ",")
+		}
+		err := out.Row(post.ID, post.Title, post.Url, post.FeedName, post.PublishedAt, post.Revisions > 0, changed)
+		if err != nil {
+			return fmt.Errorf("browse: %w", err)
+		}
+	}
+	if err := out.Flush(); err != nil {
+		return fmt.Errorf("browse: %w", err)
+	}
+
+	// a full page may be followed by more posts, the hint goes to stderr so
+	// it doesn't end up in piped output
+	if len(posts) > 0 && len(posts) == int(params.Limit) {
+		last := posts[len(posts)-1]
+		fmt.Fprintf(os.Stderr, "Next page: --page %s\n", encodeCursor(last.SortKey, last.ID))
 	}
 
 	return nil
 }
+
+// encodeCursor packs the sort key and id of the last post shown into an
+// opaque token for browse --page.
+func encodeCursor(key time.Time, id uuid.UUID) string {
+	raw := fmt.Sprintf("%d.%s", key.UnixNano(), id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.UUID{}, err
+	}
+	nanos, rawID, ok := strings.Cut(string(raw), ".")
+	if !ok {
+		return time.Time{}, uuid.UUID{}, errors.New("malformed cursor")
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return time.Time{}, uuid.UUID{}, err
+	}
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return time.Time{}, uuid.UUID{}, err
+	}
+	// timestamps are stored without a zone, keep them in UTC like pq returns them
+	return time.Unix(0, n).UTC(), id, nil
+}
+
+// postChanges names the fields that differ between a post and its previous
+// revision.
+func postChanges(previous database.PostRevision, post database.BrowsePostsForUserRow) []string {
+	var changed []string
+	if previous.Title != post.Title {
+		changed = append(changed, "title")
+	}
+	if previous.Url != post.Url {
+		changed = append(changed, "url")
+	}
+	if previous.Description != post.Description {
+		changed = append(changed, "description")
+	}
+	if !previous.PublishedAt.Equal(post.PublishedAt) {
+		changed = append(changed, "published_at")
+	}
+	if previous.Content != post.Content {
+		changed = append(changed, "content")
+	}
+	if previous.Author != post.Author {
+		changed = append(changed, "author")
+	}
+	return changed
+}
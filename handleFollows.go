@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"gator/internal/database"
 	"time"
@@ -16,13 +18,21 @@ func printFollow(follows []database.CreateFeedFollowRow) {
 }
 
 func handleFollow(s *state, cmd command, user database.User) error {
-	if len(cmd.Args) != 1 {
-		return fmt.Errorf("follow: invalid arguments, expected %d but got %d", 1, len(cmd.Args))
-	}
 	url := cmd.Args[0]
 	ctx := context.Background()
 
 	feed, err := s.db.GetFeed(ctx, url)
+	if errors.Is(err, sql.ErrNoRows) {
+		// the user may have given the site rather than its feed
+		feedUrl, discoverErr := discoverFeedURL(ctx, url)
+		if discoverErr != nil {
+			return fmt.Errorf("follow: %w", discoverErr)
+		}
+		feed, err = s.db.GetFeed(ctx, feedUrl)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("follow: feed %s is not known yet, add it with 'gator addfeed <name> %s'", feedUrl, feedUrl)
+		}
+	}
 	if err != nil {
 		return fmt.Errorf("follow: failed to find feed: %w", err)
 	}
@@ -44,29 +54,22 @@ func handleFollow(s *state, cmd command, user database.User) error {
 }
 
 func handleFollowing(s *state, cmd command, user database.User) error {
-	if len(cmd.Args) != 0 {
-		return fmt.Errorf("following: invalid arguments, expected %d but got %d", 0, len(cmd.Args))
-	}
-
 	ctx := context.Background()
 	follows, err := s.db.GetFeedFollowsForUser(ctx, user.Name)
 	if err != nil {
 		return fmt.Errorf("following: failed to find follows for current user: %w", err)
 	}
 
-	fmt.Printf("%s follows:\n", user.Name)
+	out := s.newOutput("feed_name", "feed_url", "folder", "unread")
 	for _, f := range follows {
-		fmt.Printf("Feed Name:\t%s\nFeed URL:\t%s\n", f.FeedName, f.FeedUrl)
-		fmt.Println("---")
+		if err := out.Row(f.FeedName, f.FeedUrl, f.Folder, f.Unread); err != nil {
+			return fmt.Errorf("following: %w", err)
+		}
 	}
-
-	return nil
+	return out.Flush()
 }
 
 func handleUnfollow(s *state, cmd command, user database.User) error {
-	if len(cmd.Args) != 1 {
-		return fmt.Errorf("unfollow: invalid arguments, expected %d but got %d", 1, len(cmd.Args))
-	}
 	url := cmd.Args[0]
 	ctx := context.Background()
 
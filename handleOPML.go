@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"gator/internal/database"
+	"gator/internal/opml"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func handleImport(s *state, cmd command, user database.User) error {
+	if cmd.Args[0] != "opml" {
+		return usageErrorf("unsupported format '%s', expected opml", cmd.Args[0])
+	}
+
+	file, err := os.Open(cmd.Args[1])
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	defer file.Close()
+
+	doc, err := opml.Parse(file)
+	if err != nil {
+		return fmt.Errorf("import: invalid OPML: %w", err)
+	}
+
+	ctx := context.Background()
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := s.db.WithTx(tx)
+
+	var created, existing, followed int
+	var invalid []string
+	seen := make(map[string]bool)
+	for _, sub := range doc.Subscriptions() {
+		if !isFeedURL(sub.XMLURL) {
+			invalid = append(invalid, fmt.Sprintf("'%s': invalid feed URL '%s'", sub.Title, sub.XMLURL))
+			continue
+		}
+		if seen[sub.XMLURL] {
+			existing++
+			continue
+		}
+		seen[sub.XMLURL] = true
+
+		feed, err := qtx.GetFeed(ctx, sub.XMLURL)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			name := sub.Title
+			if name == "" {
+				name = sub.XMLURL
+			}
+			feed, err = qtx.CreateFeed(ctx, database.CreateFeedParams{
+				ID:        uuid.New(),
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+				Name:      name,
+				Url:       sub.XMLURL,
+				UserID:    user.ID,
+			})
+			if err != nil {
+				return fmt.Errorf("import: failed to create feed '%s': %w", sub.XMLURL, err)
+			}
+			created++
+		case err != nil:
+			return fmt.Errorf("import: %w", err)
+		default:
+			existing++
+		}
+
+		rows, err := qtx.CreateFeedFollowIfMissing(ctx, database.CreateFeedFollowIfMissingParams{
+			ID:        uuid.New(),
+			UserID:    user.ID,
+			FeedID:    feed.ID,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			Folder:    sql.NullString{String: sub.Folder, Valid: sub.Folder != ""},
+		})
+		if err != nil {
+			return fmt.Errorf("import: failed to follow '%s': %w", sub.XMLURL, err)
+		}
+		followed += int(rows)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+
+	for _, msg := range invalid {
+		fmt.Printf("skipped %s\n", msg)
+	}
+	fmt.Printf("Imported '%s': %d feeds created, %d already existed, %d invalid, %d newly followed\n",
+		cmd.Args[1], created, existing, len(invalid), followed)
+	return nil
+}
+
+func handleExport(s *state, cmd command, user database.User) error {
+	if cmd.Args[0] != "opml" {
+		return usageErrorf("unsupported format '%s', expected opml", cmd.Args[0])
+	}
+
+	follows, err := s.db.GetFeedFollowsForUser(context.Background(), user.Name)
+	if err != nil {
+		return fmt.Errorf("export: failed to find follows for current user: %w", err)
+	}
+
+	subs := make([]opml.Subscription, 0, len(follows))
+	for _, f := range follows {
+		subs = append(subs, opml.Subscription{
+			Title:  f.FeedName,
+			XMLURL: f.FeedUrl,
+			Folder: f.Folder.String,
+		})
+	}
+	doc := opml.New(fmt.Sprintf("%s's gator subscriptions", user.Name), subs)
+
+	// without a file the document goes to stdout so it can be piped
+	if len(cmd.Args) == 1 {
+		return doc.Write(os.Stdout)
+	}
+
+	file, err := os.Create(cmd.Args[1])
+	if err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+	defer file.Close()
+
+	if err := doc.Write(file); err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+
+	fmt.Printf("Exported %d subscriptions to '%s'\n", len(subs), cmd.Args[1])
+	return nil
+}
+
+func isFeedURL(rawUrl string) bool {
+	u, err := url.Parse(rawUrl)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gator/internal/database"
+	"gator/internal/htmltext"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/term"
+)
+
+// showMaxWidth keeps lines readable on wide terminals.
+const showMaxWidth = 100
+
+func handleRead(s *state, cmd command, user database.User) error {
+	postID, err := uuid.Parse(cmd.Args[0])
+	if err != nil {
+		return usageErrorf("invalid post id: %v", err)
+	}
+
+	ctx := context.Background()
+
+	post, err := s.db.GetPost(ctx, postID)
+	if err != nil {
+		return fmt.Errorf("read: post not found: %w", err)
+	}
+
+	err = s.db.MarkPostRead(ctx, database.MarkPostReadParams{
+		UserID: user.ID,
+		PostID: post.ID,
+		ReadAt: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("read: %w", err)
+	}
+
+	fmt.Printf("Marked '%s' as read\n", post.Title)
+	return nil
+}
+
+func handleMarkRead(s *state, cmd command, user database.User) error {
+	// marking everything must be asked for explicitly
+	if len(cmd.Flags) == 0 {
+		return usageErrorf("expected --feed, --before or --all")
+	}
+
+	ctx := context.Background()
+
+	params := database.MarkPostsReadParams{
+		UserID: user.ID,
+		ReadAt: time.Now(),
+	}
+	if url, ok := cmd.Flag("feed"); ok {
+		feed, err := s.db.GetFeed(ctx, url)
+		if err != nil {
+			return fmt.Errorf("mark-read: feed not found: %w", err)
+		}
+		params.FeedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+	if value, ok := cmd.Flag("before"); ok {
+		before, err := parseSince(value, time.Now())
+		if err != nil {
+			return usageErrorf("%v", err)
+		}
+		params.PublishedBefore = sql.NullTime{Time: before, Valid: true}
+	}
+
+	count, err := s.db.MarkPostsRead(ctx, params)
+	if err != nil {
+		return fmt.Errorf("mark-read: %w", err)
+	}
+
+	fmt.Printf("Marked %d posts as read\n", count)
+	return nil
+}
+
+func handleShow(s *state, cmd command) error {
+	postID, err := uuid.Parse(cmd.Args[0])
+	if err != nil {
+		return usageErrorf("invalid post id: %v", err)
+	}
+
+	width := 80
+	if w, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+		width = min(w, showMaxWidth)
+	}
+	if value, ok := cmd.Flag("width"); ok {
+		width, err = strconv.Atoi(value)
+		if err != nil || width < 1 {
+			return usageErrorf("invalid width '%s'", value)
+		}
+	}
+
+	post, err := s.db.GetPost(context.Background(), postID)
+	if err != nil {
+		return fmt.Errorf("show: post not found: %w", err)
+	}
+
+	// relative links in the content point into the post's site
+	base, err := url.Parse(post.Url)
+	if err != nil {
+		base = nil
+	}
+	fmt.Println(htmltext.Printable(post.Title))
+	if post.Author.Valid {
+		fmt.Printf("by %s\n", htmltext.Printable(post.Author.String))
+	}
+	fmt.Printf("%s\n%s\n\n", htmltext.Printable(post.Url), post.PublishedAt.Format(time.DateTime))
+	fmt.Println(htmltext.Render(postBody(post.Content, post.Description), width, base))
+	return nil
+}
+
+// postBody returns the full content of a post when the feed provided it,
+// its description otherwise.
+func postBody(content, description sql.NullString) string {
+	if content.String != "" {
+		return content.String
+	}
+	return description.String
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"gator/internal/database"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func handleSave(s *state, cmd command, user database.User) error {
+	postID, err := uuid.Parse(cmd.Args[0])
+	if err != nil {
+		return usageErrorf("invalid post id: %v", err)
+	}
+
+	ctx := context.Background()
+
+	post, err := s.db.GetPost(ctx, postID)
+	if err != nil {
+		return fmt.Errorf("save: post not found: %w", err)
+	}
+
+	rows, err := s.db.SavePost(ctx, database.SavePostParams{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		PostID:    post.ID,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("save: %w", err)
+	}
+
+	// the insert is skipped for posts already saved
+	if rows == 0 {
+		fmt.Println("Post already saved")
+		return nil
+	}
+	fmt.Println("Post saved")
+	return nil
+}
+
+func handleUnsave(s *state, cmd command, user database.User) error {
+	id, err := uuid.Parse(cmd.Args[0])
+	if err != nil {
+		return usageErrorf("invalid id: %v", err)
+	}
+
+	rows, err := s.db.UnsavePost(context.Background(), database.UnsavePostParams{
+		UserID: user.ID,
+		ID:     id,
+	})
+	if err != nil {
+		return fmt.Errorf("unsave: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("unsave: no saved post with id '%s'", id)
+	}
+
+	fmt.Println("Post removed from saved")
+	return nil
+}
+
+func handleSaved(s *state, cmd command, user database.User) error {
+	saved, err := s.db.GetSavedPosts(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("saved: failed to fetch saved posts: %w", err)
+	}
+
+	out := s.newOutput("id", "post_id", "title", "url", "feed", "published_at", "saved_at")
+	for _, post := range saved {
+		err := out.Row(post.ID, post.PostID, post.Title, post.Url, post.FeedName, post.PublishedAt, post.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("saved: %w", err)
+		}
+	}
+	return out.Flush()
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gator/internal/database"
+	"gator/internal/pubdate"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const defaultSearchLimit = 10
+
+func handleSearch(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+
+	params := database.SearchPostsForUserParams{
+		UserID: user.ID,
+		Query:  strings.Join(cmd.Args, " "),
+		Limit:  defaultSearchLimit,
+	}
+	if url, ok := cmd.Flag("feed"); ok {
+		feed, err := s.db.GetFeed(ctx, url)
+		if err != nil {
+			return fmt.Errorf("search: feed not found: %w", err)
+		}
+		params.FeedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+	if value, ok := cmd.Flag("category"); ok {
+		params.Category = sql.NullString{String: value, Valid: true}
+	}
+	if value, ok := cmd.Flag("since"); ok {
+		since, err := parseSince(value, time.Now())
+		if err != nil {
+			return usageErrorf("%v", err)
+		}
+		params.PublishedSince = sql.NullTime{Time: since, Valid: true}
+	}
+	if value, ok := cmd.Flag("limit"); ok {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return usageErrorf("invalid limit '%s'", value)
+		}
+		params.Limit = int32(limit)
+	}
+
+	results, err := s.db.SearchPostsForUser(ctx, params)
+	if err != nil {
+		return fmt.Errorf("search: %w", err)
+	}
+
+	out := s.newOutput("id", "title", "url", "feed", "published_at", "rank", "snippet")
+	for _, r := range results {
+		snippet := strings.Join(strings.Fields(html.UnescapeString(r.Snippet)), " ")
+		if err := out.Row(r.ID, r.Title, r.Url, r.FeedName, r.PublishedAt, r.Rank, snippet); err != nil {
+			return fmt.Errorf("search: %w", err)
+		}
+	}
+	return out.Flush()
+}
+
+// parseSince accepts a relative age such as "7d", "2w" or "36h", counted back
+// from now, or an absolute date. Like pubdate.Parse it returns UTC, to
+// compare with published_at.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	now = now.UTC()
+	units := map[string]time.Duration{
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	}
+	for suffix, unit := range units {
+		if n, ok := strings.CutSuffix(value, suffix); ok {
+			if count, err := strconv.Atoi(n); err == nil {
+				return now.Add(-time.Duration(count) * unit), nil
+			}
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+
+	t, err := pubdate.Parse(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time '%s', expected an age like 7d or a date", value)
+	}
+	return t, nil
+}
@@ -0,0 +1,431 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gator/internal/database"
+	"gator/internal/htmltext"
+	"gator/internal/tui"
+	"math"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// tuiPostLimit bounds how many posts the reader lists at once.
+const tuiPostLimit = 200
+
+const tuiHelp = "tab pane  j/k move  enter open  r read  R read all  s save  o browser  u unread only  q quit"
+
+type tuiPane int
+
+const (
+	paneFeeds tuiPane = iota
+	panePosts
+	paneBody
+)
+
+// reader is the state of the tui command. The first row of the feeds pane
+// stands for all followed feeds, so feed is one past the index into feeds.
+type reader struct {
+	s    *state
+	user database.User
+
+	feeds []database.GetFeedFollowsForUserRow
+	posts []database.BrowsePostsForUserRow
+
+	focus      tuiPane
+	feed       int
+	post       int
+	feedTop    int
+	postTop    int
+	bodyTop    int
+	unreadOnly bool
+	status     string
+	// rows is the height of the panes as last drawn
+	rows int
+}
+
+func handleTUI(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+
+	r := &reader{s: s, user: user, status: tuiHelp}
+	if err := r.loadFeeds(ctx); err != nil {
+		return fmt.Errorf("tui: %w", err)
+	}
+	if err := r.loadPosts(ctx); err != nil {
+		return fmt.Errorf("tui: %w", err)
+	}
+
+	screen, err := tui.Open()
+	if err != nil {
+		return fmt.Errorf("tui: %w", err)
+	}
+	defer screen.Close()
+
+	keys := make(chan string)
+	readErr := make(chan error, 1)
+	go func() {
+		for {
+			key, err := screen.ReadKey()
+			if err != nil {
+				readErr <- err
+				return
+			}
+			keys <- key
+		}
+	}()
+
+	// there is no portable resize signal, so the size is polled
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+
+	var width, height int
+	redraw := true
+	for {
+		if w, h := screen.Size(); redraw || w != width || h != height {
+			width, height = w, h
+			if err := screen.Draw(r.render(width, height)); err != nil {
+				return fmt.Errorf("tui: %w", err)
+			}
+			redraw = false
+		}
+
+		select {
+		case key := <-keys:
+			quit, err := r.handleKey(ctx, key)
+			if quit {
+				return nil
+			}
+			if err != nil {
+				r.status = err.Error()
+			}
+			redraw = true
+		case err := <-readErr:
+			return fmt.Errorf("tui: %w", err)
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *reader) loadFeeds(ctx context.Context) error {
+	feeds, err := r.s.db.GetFeedFollowsForUser(ctx, r.user.Name)
+	if err != nil {
+		return err
+	}
+	r.feeds = feeds
+	r.feed = min(r.feed, len(feeds))
+	return nil
+}
+
+func (r *reader) loadPosts(ctx context.Context) error {
+	params := database.BrowsePostsForUserParams{
+		UserID:     r.user.ID,
+		SortBy:     "published",
+		UnreadOnly: r.unreadOnly,
+		Limit:      tuiPostLimit,
+	}
+	if r.feed > 0 {
+		params.FeedID = uuid.NullUUID{UUID: r.feeds[r.feed-1].FeedID, Valid: true}
+	}
+	posts, err := r.s.db.BrowsePostsForUser(ctx, params)
+	if err != nil {
+		return err
+	}
+	r.posts = posts
+	r.post = max(0, min(r.post, len(posts)-1))
+	return nil
+}
+
+// handleKey applies a key press and reports whether the reader should quit.
+func (r *reader) handleKey(ctx context.Context, key string) (bool, error) {
+	r.status = tuiHelp
+	switch key {
+	case "q", "ctrl+c":
+		return true, nil
+	case "tab", "right", "l":
+		r.focus = min(r.focus+1, paneBody)
+	case "backtab", "left", "h", "esc":
+		r.focus = max(r.focus-1, paneFeeds)
+	case "down", "j":
+		return false, r.move(ctx, 1)
+	case "up", "k":
+		return false, r.move(ctx, -1)
+	case "pgdown", " ":
+		return false, r.move(ctx, max(1, r.rows-1))
+	case "pgup", "b":
+		return false, r.move(ctx, -max(1, r.rows-1))
+	case "home", "g":
+		return false, r.move(ctx, -math.MaxInt32)
+	case "end", "G":
+		return false, r.move(ctx, math.MaxInt32)
+	case "enter":
+		if r.focus == paneFeeds {
+			r.focus = panePosts
+			return false, nil
+		}
+		r.focus = paneBody
+		return false, r.markRead(ctx)
+	case "r":
+		return false, r.markRead(ctx)
+	case "R":
+		return false, r.markAllRead(ctx)
+	case "s":
+		return false, r.save(ctx)
+	case "o":
+		if len(r.posts) == 0 {
+			return false, nil
+		}
+		return false, openInBrowser(r.posts[r.post].Url)
+	case "u":
+		r.unreadOnly = !r.unreadOnly
+		r.post, r.postTop, r.bodyTop = 0, 0, 0
+		return false, r.loadPosts(ctx)
+	}
+	return false, nil
+}
+
+// move moves the selection of the focused pane, or scrolls the post body.
+func (r *reader) move(ctx context.Context, delta int) error {
+	switch r.focus {
+	case paneFeeds:
+		feed := max(0, min(r.feed+delta, len(r.feeds)))
+		if feed == r.feed {
+			return nil
+		}
+		r.feed = feed
+		r.post, r.postTop, r.bodyTop = 0, 0, 0
+		return r.loadPosts(ctx)
+	case panePosts:
+		post := max(0, min(r.post+delta, len(r.posts)-1))
+		if post != r.post {
+			r.post, r.bodyTop = post, 0
+		}
+	case paneBody:
+		// the upper bound depends on the width, render clamps it
+		r.bodyTop = max(0, r.bodyTop+delta)
+	}
+	return nil
+}
+
+func (r *reader) markRead(ctx context.Context) error {
+	if len(r.posts) == 0 || r.posts[r.post].IsRead {
+		return nil
+	}
+	post := &r.posts[r.post]
+	err := r.s.db.MarkPostRead(ctx, database.MarkPostReadParams{
+		UserID: r.user.ID,
+		PostID: post.ID,
+		ReadAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	// the post stays listed until the posts are loaded again
+	post.IsRead = true
+	return r.loadFeeds(ctx)
+}
+
+func (r *reader) markAllRead(ctx context.Context) error {
+	params := database.MarkPostsReadParams{
+		UserID: r.user.ID,
+		ReadAt: time.Now(),
+	}
+	if r.feed > 0 {
+		params.FeedID = uuid.NullUUID{UUID: r.feeds[r.feed-1].FeedID, Valid: true}
+	}
+	count, err := r.s.db.MarkPostsRead(ctx, params)
+	if err != nil {
+		return err
+	}
+	for i := range r.posts {
+		r.posts[i].IsRead = true
+	}
+	r.status = fmt.Sprintf("Marked %d posts as read", count)
+	return r.loadFeeds(ctx)
+}
+
+func (r *reader) save(ctx context.Context) error {
+	if len(r.posts) == 0 {
+		return nil
+	}
+	rows, err := r.s.db.SavePost(ctx, database.SavePostParams{
+		ID:        uuid.New(),
+		UserID:    r.user.ID,
+		PostID:    r.posts[r.post].ID,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		r.status = "Post already saved"
+	} else {
+		r.status = "Post saved"
+	}
+	return nil
+}
+
+// openInBrowser starts $BROWSER on rawURL. As is customary $BROWSER may list
+// several commands separated by colons, only the first is used, and may
+// place the URL with %s.
+func openInBrowser(rawURL string) error {
+	// the URL comes from the feed: only web pages are opened, and as the
+	// URL then starts with its scheme it can't be taken for an option
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("not opening '%s', only http and https links are", rawURL)
+	}
+	link := u.String()
+
+	browser, _, _ := strings.Cut(os.Getenv("BROWSER"), ":")
+	args := strings.Fields(browser)
+	if len(args) == 0 {
+		return errors.New("set $BROWSER to open links")
+	}
+	if strings.Contains(browser, "%s") {
+		for i := range args {
+			args[i] = strings.ReplaceAll(args[i], "%s", link)
+		}
+	} else {
+		args = append(args, link)
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to open browser: %w", err)
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// render lays out the three panes side by side above a status line.
+func (r *reader) render(width, height int) []string {
+	r.rows = max(1, height-2)
+	feedsWidth := max(16, width/5)
+	postsWidth := max(24, width*2/5)
+	bodyWidth := width - feedsWidth - postsWidth - 2
+
+	header := func(pane tuiPane, title string, w int) string {
+		if pane == r.focus {
+			return tui.Reverse(tui.Fit(" "+title, w))
+		}
+		return tui.Bold(tui.Fit(" "+title, w))
+	}
+	postsTitle := "Posts"
+	if r.unreadOnly {
+		postsTitle = "Unread posts"
+	}
+
+	feeds := r.feedLines(feedsWidth)
+	posts := r.postLines(postsWidth)
+	body := r.bodyLines(bodyWidth)
+
+	lines := make([]string, 0, height)
+	lines = append(lines, header(paneFeeds, "Feeds", feedsWidth)+"│"+
+		header(panePosts, postsTitle, postsWidth)+"│"+
+		header(paneBody, "Post", bodyWidth))
+	for i := range r.rows {
+		lines = append(lines, feeds[i]+"│"+posts[i]+"│"+body[i])
+	}
+	return append(lines, tui.Dim(tui.Fit(r.status, width)))
+}
+
+// list renders the rows of a list pane, scrolling top so the selected row is
+// visible.
+func (r *reader) list(items []string, selected int, top *int, pane tuiPane, width int) []string {
+	if selected < *top {
+		*top = selected
+	}
+	if selected >= *top+r.rows {
+		*top = selected - r.rows + 1
+	}
+
+	lines := make([]string, r.rows)
+	for i := range lines {
+		n := *top + i
+		switch {
+		case n >= len(items):
+			lines[i] = tui.Fit("", width)
+		case n == selected && pane == r.focus:
+			lines[i] = tui.Reverse(tui.Fit(items[n], width))
+		case n == selected:
+			lines[i] = tui.Bold(tui.Fit(items[n], width))
+		default:
+			lines[i] = tui.Fit(items[n], width)
+		}
+	}
+	return lines
+}
+
+func (r *reader) feedLines(width int) []string {
+	var total int64
+	items := make([]string, 0, len(r.feeds)+1)
+	items = append(items, "")
+	for _, f := range r.feeds {
+		total += f.Unread
+		items = append(items, withCount(f.FeedName, f.Unread, width))
+	}
+	items[0] = withCount("All feeds", total, width)
+	return r.list(items, r.feed, &r.feedTop, paneFeeds, width)
+}
+
+// withCount right-aligns a non-zero count after label.
+func withCount(label string, count int64, width int) string {
+	if count == 0 {
+		return " " + label
+	}
+	n := strconv.FormatInt(count, 10)
+	return tui.Fit(" "+label, width-len(n)-1) + n
+}
+
+func (r *reader) postLines(width int) []string {
+	items := make([]string, len(r.posts))
+	for i, p := range r.posts {
+		mark := "•"
+		if p.IsRead {
+			mark = " "
+		}
+		items[i] = fmt.Sprintf("%s %s %s", mark, p.PublishedAt.Format("Jan 02"), p.Title)
+	}
+	if len(items) == 0 {
+		items = append(items, " No posts")
+	}
+	return r.list(items, r.post, &r.postTop, panePosts, width)
+}
+
+func (r *reader) bodyLines(width int) []string {
+	var lines []string
+	if len(r.posts) > 0 && width > 2 {
+		post := r.posts[r.post]
+		inner := width - 2
+		for _, line := range tui.Wrap(post.Title, inner) {
+			lines = append(lines, tui.Bold(tui.Fit(" "+line, width)))
+		}
+		meta := fmt.Sprintf("%s · %s", post.FeedName, post.PublishedAt.Format(time.DateTime))
+		if post.Author.Valid {
+			meta = fmt.Sprintf("%s · %s", post.Author.String, meta)
+		}
+		lines = append(lines, tui.Dim(tui.Fit(" "+meta, width)), tui.Dim(tui.Fit(" "+post.Url, width)), tui.Fit("", width))
+		base, err := url.Parse(post.Url)
+		if err != nil {
+			base = nil
+		}
+		for _, line := range strings.Split(htmltext.Render(postBody(post.Content, post.Description), inner, base), "\n") {
+			lines = append(lines, tui.Fit(" "+line, width))
+		}
+	}
+
+	r.bodyTop = max(0, min(r.bodyTop, len(lines)-r.rows))
+	lines = lines[min(r.bodyTop, len(lines)):]
+	for len(lines) < r.rows {
+		lines = append(lines, tui.Fit("", width))
+	}
+	return lines[:r.rows]
+}
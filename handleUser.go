@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"gator/internal/database"
 	"time"
@@ -11,9 +10,6 @@ import (
 )
 
 func handleLogin(s *state, cmd command) error {
-	if len(cmd.Args) != 1 {
-		return errors.New("login: invalid arguments for 'login' command")
-	}
 	username := cmd.Args[0]
 
 	user, err := s.db.GetUser(context.Background(), username)
@@ -32,10 +28,6 @@ func handleLogin(s *state, cmd command) error {
 }
 
 func handleRegister(s *state, cmd command) error {
-	if len(cmd.Args) != 1 {
-		return errors.New("register: invalid arguments for 'register' command")
-	}
-
 	ctx := context.Background()
 
 	name := cmd.Args[0]
@@ -82,12 +74,11 @@ func handleUsers(s *state, cmd command) error {
 		return fmt.Errorf("users: %w", err)
 	}
 
+	out := s.newOutput("id", "name", "current")
 	for _, user := range users {
-		if user.Name == s.Config.CurrentUsername {
-			fmt.Printf("%s: %s (current)\n", user.ID, user.Name)
-		} else {
-			fmt.Printf("%s: %s\n", user.ID, user.Name)
+		if err := out.Row(user.ID, user.Name, user.Name == s.Config.CurrentUsername); err != nil {
+			return fmt.Errorf("users: %w", err)
 		}
 	}
-	return nil
+	return out.Flush()
 }
@@ -9,8 +9,13 @@ import (
 type Config struct {
 	DbUrl           string `json:"db_url"`
 	CurrentUsername string `json:"current_user_name"`
+	// MaxFeedFailures is how many fetches in a row may fail before agg
+	// disables a feed, DefaultMaxFeedFailures when unset.
+	MaxFeedFailures int `json:"max_feed_failures,omitempty"`
 }
 
+const DefaultMaxFeedFailures = 10
+
 const configFileName = ".gatorconfig.json"
 
 func Read() (Config, error) {
@@ -32,6 +37,13 @@ func Read() (Config, error) {
 	return config, nil
 }
 
+func (c Config) FeedFailureLimit() int {
+	if c.MaxFeedFailures > 0 {
+		return c.MaxFeedFailures
+	}
+	return DefaultMaxFeedFailures
+}
+
 func (c *Config) SetUser(username string) error {
 	c.CurrentUsername = username
 	err := write(*c)
@@ -0,0 +1,130 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: categories.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+const addPostCategory = `-- name: AddPostCategory :exec
+INSERT INTO post_categories (post_id, category_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING
+`
+
+type AddPostCategoryParams struct {
+	PostID     uuid.UUID
+	CategoryID uuid.UUID
+}
+
+func (q *Queries) AddPostCategory(ctx context.Context, arg AddPostCategoryParams) error {
+	_, err := q.db.ExecContext(ctx, addPostCategory, arg.PostID, arg.CategoryID)
+	return err
+}
+
+const createCategory = `-- name: CreateCategory :one
+INSERT INTO categories (id, name)
+VALUES ($1, LOWER($2))
+ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+RETURNING id, name
+`
+
+type CreateCategoryParams struct {
+	ID   uuid.UUID
+	Name string
+}
+
+// Returns the category with the given name, creating it if needed. Names are
+// stored lower case so filters match regardless of how feeds spell them.
+func (q *Queries) CreateCategory(ctx context.Context, arg CreateCategoryParams) (Category, error) {
+	row := q.db.QueryRowContext(ctx, createCategory, arg.ID, arg.Name)
+	var i Category
+	err := row.Scan(&i.ID, &i.Name)
+	return i, err
+}
+
+const deletePostCategories = `-- name: DeletePostCategories :exec
+DELETE FROM post_categories WHERE post_id = $1
+`
+
+func (q *Queries) DeletePostCategories(ctx context.Context, postID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deletePostCategories, postID)
+	return err
+}
+
+const getCategories = `-- name: GetCategories :many
+SELECT name FROM categories ORDER BY name
+`
+
+func (q *Queries) GetCategories(ctx context.Context) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, getCategories)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostCategories = `-- name: GetPostCategories :many
+SELECT posts.id, categories.name
+FROM posts
+LEFT JOIN post_categories ON post_categories.post_id = posts.id
+LEFT JOIN categories ON categories.id = post_categories.category_id
+WHERE posts.feed_id = $1 AND posts.guid = $2
+ORDER BY categories.name
+`
+
+type GetPostCategoriesParams struct {
+	FeedID uuid.UUID
+	Guid   string
+}
+
+type GetPostCategoriesRow struct {
+	ID   uuid.UUID
+	Name sql.NullString
+}
+
+// Returns the post with the given GUID in a feed once per category, or once
+// with a NULL name when it has none.
+func (q *Queries) GetPostCategories(ctx context.Context, arg GetPostCategoriesParams) ([]GetPostCategoriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostCategories, arg.FeedID, arg.Guid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostCategoriesRow
+	for rows.Next() {
+		var i GetPostCategoriesRow
+		if err := rows.Scan(&i.ID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,10 +17,10 @@ const createFeedFollow = `-- name: CreateFeedFollow :many
 WITH inserted_feed_follow AS (
     INSERT INTO feed_follows (id, user_id, feed_id, created_at, updated_at)
     VALUES ($1, $2, $3, $4, $5)
-    RETURNING id, user_id, feed_id, created_at, updated_at
+    RETURNING id, user_id, feed_id, created_at, updated_at, folder
 )
 SELECT
-    inserted_feed_follow.id, inserted_feed_follow.user_id, inserted_feed_follow.feed_id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at,
+    inserted_feed_follow.id, inserted_feed_follow.user_id, inserted_feed_follow.feed_id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.folder,
     feeds.name AS feed_name,
     users.name AS user_name
 FROM inserted_feed_follow
@@ -41,6 +42,7 @@ type CreateFeedFollowRow struct {
 	FeedID    uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	Folder    sql.NullString
 	FeedName  string
 	UserName  string
 }
@@ -66,6 +68,7 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 			&i.FeedID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Folder,
 			&i.FeedName,
 			&i.UserName,
 		); err != nil {
@@ -82,6 +85,36 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 	return items, nil
 }
 
+const createFeedFollowIfMissing = `-- name: CreateFeedFollowIfMissing :execrows
+INSERT INTO feed_follows (id, user_id, feed_id, created_at, updated_at, folder)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (user_id, feed_id) DO NOTHING
+`
+
+type CreateFeedFollowIfMissingParams struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	FeedID    uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Folder    sql.NullString
+}
+
+func (q *Queries) CreateFeedFollowIfMissing(ctx context.Context, arg CreateFeedFollowIfMissingParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createFeedFollowIfMissing,
+		arg.ID,
+		arg.UserID,
+		arg.FeedID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.Folder,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const deleteFollow = `-- name: DeleteFollow :exec
 DELETE FROM feed_follows
 WHERE user_id = $1 AND feed_id = $2
@@ -103,11 +136,23 @@ SELECT
     users.id AS user_id,
     feeds.name AS feed_name,
     feeds.url AS feed_url,
-    feeds.id AS feed_id
+    feeds.id AS feed_id,
+    feed_follows.folder AS folder,
+    (
+        SELECT COUNT(*)
+        FROM posts
+        WHERE posts.feed_id = feeds.id
+            AND NOT EXISTS (
+                SELECT 1
+                FROM post_reads
+                WHERE post_reads.post_id = posts.id AND post_reads.user_id = users.id
+            )
+    ) AS unread
 FROM feed_follows
 INNER JOIN users ON users.id = feed_follows.user_id
 INNER JOIN feeds ON feeds.id = feed_follows.feed_id
 WHERE users.name = $1
+ORDER BY feed_follows.folder NULLS FIRST, feeds.name
 `
 
 type GetFeedFollowsForUserRow struct {
@@ -116,6 +161,8 @@ type GetFeedFollowsForUserRow struct {
 	FeedName string
 	FeedUrl  string
 	FeedID   uuid.UUID
+	Folder   sql.NullString
+	Unread   int64
 }
 
 func (q *Queries) GetFeedFollowsForUser(ctx context.Context, name string) ([]GetFeedFollowsForUserRow, error) {
@@ -133,6 +180,8 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, name string) ([]Get
 			&i.FeedName,
 			&i.FeedUrl,
 			&i.FeedID,
+			&i.Folder,
+			&i.Unread,
 		); err != nil {
 			return nil, err
 		}
@@ -146,3 +195,25 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, name string) ([]Get
 	}
 	return items, nil
 }
+
+const moveFeedFollows = `-- name: MoveFeedFollows :exec
+UPDATE feed_follows
+SET feed_id = $1, updated_at = NOW()
+WHERE feed_follows.feed_id = $2
+    AND feed_follows.user_id NOT IN (
+        SELECT existing.user_id
+        FROM feed_follows existing
+        WHERE existing.feed_id = $1
+    )
+`
+
+type MoveFeedFollowsParams struct {
+	ToFeedID   uuid.UUID
+	FromFeedID uuid.UUID
+}
+
+// Moves follows to another feed, skipping users who already follow it.
+func (q *Queries) MoveFeedFollows(ctx context.Context, arg MoveFeedFollowsParams) error {
+	_, err := q.db.ExecContext(ctx, moveFeedFollows, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
@@ -7,15 +7,17 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createFeed = `-- name: CreateFeed :one
 INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
 VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, next_fetch_at, fetch_interval_seconds, ttl_seconds, skip_hours, skip_days, consecutive_failures, last_error, last_status, last_success_at, disabled_at
 `
 
 type CreateFeedParams struct {
@@ -45,16 +47,92 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		&i.TtlSeconds,
+		pq.Array(&i.SkipHours),
+		pq.Array(&i.SkipDays),
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastStatus,
+		&i.LastSuccessAt,
+		&i.DisabledAt,
 	)
 	return i, err
 }
 
+const createFeedURLAlias = `-- name: CreateFeedURLAlias :exec
+INSERT INTO feed_url_aliases (url, feed_id, created_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (url) DO UPDATE
+SET feed_id = EXCLUDED.feed_id, created_at = EXCLUDED.created_at
+`
+
+type CreateFeedURLAliasParams struct {
+	Url       string
+	FeedID    uuid.UUID
+	CreatedAt time.Time
+}
+
+func (q *Queries) CreateFeedURLAlias(ctx context.Context, arg CreateFeedURLAliasParams) error {
+	_, err := q.db.ExecContext(ctx, createFeedURLAlias, arg.Url, arg.FeedID, arg.CreatedAt)
+	return err
+}
+
+const deferFeed = `-- name: DeferFeed :exec
+UPDATE feeds
+SET next_fetch_at = NOW() + $1::FLOAT * INTERVAL '1 second'
+WHERE id = $2
+`
+
+type DeferFeedParams struct {
+	DelaySeconds float64
+	ID           uuid.UUID
+}
+
+// Gives back a claimed feed that couldn't be fetched yet, to be due again
+// after delay_seconds.
+func (q *Queries) DeferFeed(ctx context.Context, arg DeferFeedParams) error {
+	_, err := q.db.ExecContext(ctx, deferFeed, arg.DelaySeconds, arg.ID)
+	return err
+}
+
+const deleteFeed = `-- name: DeleteFeed :exec
+DELETE FROM feeds
+WHERE id = $1
+`
+
+func (q *Queries) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteFeed, id)
+	return err
+}
+
+const enableFeed = `-- name: EnableFeed :exec
+UPDATE feeds
+SET consecutive_failures = 0, disabled_at = NULL, next_fetch_at = NULL, updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) EnableFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, enableFeed, id)
+	return err
+}
+
 const getFeed = `-- name: GetFeed :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, next_fetch_at, fetch_interval_seconds, ttl_seconds, skip_hours, skip_days, consecutive_failures, last_error, last_status, last_success_at, disabled_at
 FROM feeds
-WHERE url = $1
+WHERE feeds.url = $1
+    OR feeds.id = (
+        SELECT alias.feed_id
+        FROM feed_url_aliases alias
+        WHERE alias.url = $1
+    )
+LIMIT 1
 `
 
+// Looks a feed up by its current URL or any URL it was moved from.
 func (q *Queries) GetFeed(ctx context.Context, url string) (Feed, error) {
 	row := q.db.QueryRowContext(ctx, getFeed, url)
 	var i Feed
@@ -66,6 +144,18 @@ func (q *Queries) GetFeed(ctx context.Context, url string) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		&i.TtlSeconds,
+		pq.Array(&i.SkipHours),
+		pq.Array(&i.SkipDays),
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastStatus,
+		&i.LastSuccessAt,
+		&i.DisabledAt,
 	)
 	return i, err
 }
@@ -108,15 +198,78 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]GetFeedsRow, error) {
 	return items, nil
 }
 
-const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at
+const getFeedsHealth = `-- name: GetFeedsHealth :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, next_fetch_at, fetch_interval_seconds, ttl_seconds, skip_hours, skip_days, consecutive_failures, last_error, last_status, last_success_at, disabled_at
 FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
-LIMIT 1
+ORDER BY disabled_at ASC NULLS FIRST, consecutive_failures DESC, name
+`
+
+func (q *Queries) GetFeedsHealth(ctx context.Context) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedsHealth)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.NextFetchAt,
+			&i.FetchIntervalSeconds,
+			&i.TtlSeconds,
+			pq.Array(&i.SkipHours),
+			pq.Array(&i.SkipDays),
+			&i.ConsecutiveFailures,
+			&i.LastError,
+			&i.LastStatus,
+			&i.LastSuccessAt,
+			&i.DisabledAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
+UPDATE feeds
+SET
+    last_fetched_at = NOW(),
+    next_fetch_at = NOW() + $1::FLOAT * INTERVAL '1 second',
+    updated_at = NOW()
+WHERE id = (
+    SELECT due.id
+    FROM feeds due
+    WHERE due.disabled_at IS NULL
+        AND (due.next_fetch_at IS NULL OR due.next_fetch_at <= NOW())
+    ORDER BY due.next_fetch_at ASC NULLS FIRST
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, next_fetch_at, fetch_interval_seconds, ttl_seconds, skip_hours, skip_days, consecutive_failures, last_error, last_status, last_success_at, disabled_at
 `
 
-func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, getNextFeedToFetch)
+// Claims the feed that has been due the longest, provisionally pushing its
+// next_fetch_at out by claim_seconds until the fetch reschedules it. SKIP
+// LOCKED lets concurrent workers claim different feeds.
+func (q *Queries) GetNextFeedToFetch(ctx context.Context, claimSeconds float64) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getNextFeedToFetch, claimSeconds)
 	var i Feed
 	err := row.Scan(
 		&i.ID,
@@ -126,17 +279,179 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		&i.TtlSeconds,
+		pq.Array(&i.SkipHours),
+		pq.Array(&i.SkipDays),
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastStatus,
+		&i.LastSuccessAt,
+		&i.DisabledAt,
 	)
 	return i, err
 }
 
-const markFeedFetched = `-- name: MarkFeedFetched :exec
-UPDATE feeds 
-SET last_fetched_at = NOW(), updated_at = NOW()
+const moveFeedURLAliases = `-- name: MoveFeedURLAliases :exec
+UPDATE feed_url_aliases
+SET feed_id = $1
+WHERE feed_id = $2
+`
+
+type MoveFeedURLAliasesParams struct {
+	ToFeedID   uuid.UUID
+	FromFeedID uuid.UUID
+}
+
+func (q *Queries) MoveFeedURLAliases(ctx context.Context, arg MoveFeedURLAliasesParams) error {
+	_, err := q.db.ExecContext(ctx, moveFeedURLAliases, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
+
+const recordFeedFailure = `-- name: RecordFeedFailure :one
+UPDATE feeds
+SET
+    consecutive_failures = consecutive_failures + 1,
+    last_error = $1,
+    last_status = $2,
+    next_fetch_at = NOW() + $3::FLOAT * INTERVAL '1 second',
+    disabled_at = CASE
+        WHEN consecutive_failures + 1 >= $4::INTEGER THEN NOW()
+        ELSE NULL
+    END,
+    updated_at = NOW()
+WHERE id = $5
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, next_fetch_at, fetch_interval_seconds, ttl_seconds, skip_hours, skip_days, consecutive_failures, last_error, last_status, last_success_at, disabled_at
+`
+
+type RecordFeedFailureParams struct {
+	LastError    sql.NullString
+	LastStatus   sql.NullInt32
+	DelaySeconds float64
+	MaxFailures  int32
+	ID           uuid.UUID
+}
+
+// Records a failed fetch, backing off by delay_seconds and disabling the feed
+// once it reaches max_failures in a row.
+func (q *Queries) RecordFeedFailure(ctx context.Context, arg RecordFeedFailureParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, recordFeedFailure,
+		arg.LastError,
+		arg.LastStatus,
+		arg.DelaySeconds,
+		arg.MaxFailures,
+		arg.ID,
+	)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		&i.TtlSeconds,
+		pq.Array(&i.SkipHours),
+		pq.Array(&i.SkipDays),
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastStatus,
+		&i.LastSuccessAt,
+		&i.DisabledAt,
+	)
+	return i, err
+}
+
+const scheduleFeed = `-- name: ScheduleFeed :exec
+UPDATE feeds
+SET
+    consecutive_failures = 0,
+    last_error = NULL,
+    last_status = $1,
+    last_success_at = NOW(),
+    ttl_seconds = $2,
+    skip_hours = $3,
+    skip_days = $4,
+    next_fetch_at = NOW() + $5::FLOAT * INTERVAL '1 second',
+    updated_at = NOW()
+WHERE id = $6
+`
+
+type ScheduleFeedParams struct {
+	LastStatus   sql.NullInt32
+	TtlSeconds   sql.NullInt32
+	SkipHours    []int32
+	SkipDays     []int32
+	DelaySeconds float64
+	ID           uuid.UUID
+}
+
+// Records a successful fetch and when to fetch next.
+func (q *Queries) ScheduleFeed(ctx context.Context, arg ScheduleFeedParams) error {
+	_, err := q.db.ExecContext(ctx, scheduleFeed,
+		arg.LastStatus,
+		arg.TtlSeconds,
+		pq.Array(arg.SkipHours),
+		pq.Array(arg.SkipDays),
+		arg.DelaySeconds,
+		arg.ID,
+	)
+	return err
+}
+
+const setFeedCacheValidators = `-- name: SetFeedCacheValidators :exec
+UPDATE feeds
+SET etag = $2, last_modified = $3, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetFeedCacheValidatorsParams struct {
+	ID           uuid.UUID
+	Etag         sql.NullString
+	LastModified sql.NullString
+}
+
+func (q *Queries) SetFeedCacheValidators(ctx context.Context, arg SetFeedCacheValidatorsParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedCacheValidators, arg.ID, arg.Etag, arg.LastModified)
+	return err
+}
+
+const setFeedFetchInterval = `-- name: SetFeedFetchInterval :exec
+UPDATE feeds
+SET fetch_interval_seconds = $2, next_fetch_at = NULL, updated_at = NOW()
 WHERE id = $1
 `
 
-func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, markFeedFetched, id)
+type SetFeedFetchIntervalParams struct {
+	ID                   uuid.UUID
+	FetchIntervalSeconds sql.NullInt32
+}
+
+func (q *Queries) SetFeedFetchInterval(ctx context.Context, arg SetFeedFetchIntervalParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedFetchInterval, arg.ID, arg.FetchIntervalSeconds)
+	return err
+}
+
+const updateFeedURL = `-- name: UpdateFeedURL :exec
+UPDATE feeds
+SET url = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateFeedURLParams struct {
+	ID  uuid.UUID
+	Url string
+}
+
+func (q *Queries) UpdateFeedURL(ctx context.Context, arg UpdateFeedURLParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedURL, arg.ID, arg.Url)
 	return err
 }
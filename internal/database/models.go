@@ -11,14 +11,31 @@ import (
 	"github.com/google/uuid"
 )
 
+type Category struct {
+	ID   uuid.UUID
+	Name string
+}
+
 type Feed struct {
-	ID            uuid.UUID
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          string
-	Url           string
-	UserID        uuid.UUID
-	LastFetchedAt sql.NullTime
+	ID                   uuid.UUID
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	Name                 string
+	Url                  string
+	UserID               uuid.UUID
+	LastFetchedAt        sql.NullTime
+	Etag                 sql.NullString
+	LastModified         sql.NullString
+	NextFetchAt          sql.NullTime
+	FetchIntervalSeconds sql.NullInt32
+	TtlSeconds           sql.NullInt32
+	SkipHours            []int32
+	SkipDays             []int32
+	ConsecutiveFailures  int32
+	LastError            sql.NullString
+	LastStatus           sql.NullInt32
+	LastSuccessAt        sql.NullTime
+	DisabledAt           sql.NullTime
 }
 
 type FeedFollow struct {
@@ -27,17 +44,65 @@ type FeedFollow struct {
 	FeedID    uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	Folder    sql.NullString
+}
+
+type FeedUrlAlias struct {
+	Url       string
+	FeedID    uuid.UUID
+	CreatedAt time.Time
 }
 
 type Post struct {
+	ID                uuid.UUID
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	Title             string
+	Url               string
+	Description       sql.NullString
+	PublishedAt       time.Time
+	FeedID            uuid.UUID
+	Guid              string
+	ContentHash       string
+	SearchVector      interface{}
+	Content           sql.NullString
+	Author            sql.NullString
+	PublishedAtSource string
+}
+
+type PostCategory struct {
+	PostID     uuid.UUID
+	CategoryID uuid.UUID
+}
+
+type PostRead struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+	ReadAt time.Time
+}
+
+type PostRevision struct {
+	ID          uuid.UUID
+	PostID      uuid.UUID
+	CreatedAt   time.Time
+	Title       string
+	Url         string
+	Description sql.NullString
+	PublishedAt time.Time
+	Content     sql.NullString
+	Author      sql.NullString
+}
+
+type SavedPost struct {
 	ID          uuid.UUID
+	UserID      uuid.UUID
+	PostID      uuid.NullUUID
 	CreatedAt   time.Time
-	UpdatedAt   time.Time
 	Title       string
 	Url         string
 	Description sql.NullString
 	PublishedAt time.Time
-	FeedID      uuid.UUID
+	FeedName    string
 }
 
 type User struct {
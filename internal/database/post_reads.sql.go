@@ -0,0 +1,64 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: post_reads.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const markPostRead = `-- name: MarkPostRead :exec
+INSERT INTO post_reads (user_id, post_id, read_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, post_id) DO NOTHING
+`
+
+type MarkPostReadParams struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+	ReadAt time.Time
+}
+
+func (q *Queries) MarkPostRead(ctx context.Context, arg MarkPostReadParams) error {
+	_, err := q.db.ExecContext(ctx, markPostRead, arg.UserID, arg.PostID, arg.ReadAt)
+	return err
+}
+
+const markPostsRead = `-- name: MarkPostsRead :execrows
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT feed_follows.user_id, posts.id, $1
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = $2
+    AND ($3::UUID IS NULL OR posts.feed_id = $3)
+    AND ($4::TIMESTAMP IS NULL OR posts.published_at < $4)
+ON CONFLICT (user_id, post_id) DO NOTHING
+`
+
+type MarkPostsReadParams struct {
+	ReadAt          time.Time
+	UserID          uuid.UUID
+	FeedID          uuid.NullUUID
+	PublishedBefore sql.NullTime
+}
+
+// Marks the user's unread posts as read, optionally only those of one feed
+// or published before a given time.
+func (q *Queries) MarkPostsRead(ctx context.Context, arg MarkPostsReadParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markPostsRead,
+		arg.ReadAt,
+		arg.UserID,
+		arg.FeedID,
+		arg.PublishedBefore,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
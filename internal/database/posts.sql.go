@@ -13,34 +13,295 @@ import (
 	"github.com/google/uuid"
 )
 
+const adoptLegacyPost = `-- name: AdoptLegacyPost :exec
+UPDATE posts
+SET guid = $1
+WHERE posts.feed_id = $2
+    AND posts.guid = 'legacy:' || $3::TEXT
+    AND NOT EXISTS (
+        SELECT 1
+        FROM posts existing
+        WHERE existing.feed_id = $2 AND existing.guid = $1
+    )
+`
+
+type AdoptLegacyPostParams struct {
+	Guid   string
+	FeedID uuid.UUID
+	Url    string
+}
+
+// Gives a post stored before GUIDs were kept the GUID of the item with its
+// url, so CreatePost updates it instead of inserting a copy.
+func (q *Queries) AdoptLegacyPost(ctx context.Context, arg AdoptLegacyPostParams) error {
+	_, err := q.db.ExecContext(ctx, adoptLegacyPost, arg.Guid, arg.FeedID, arg.Url)
+	return err
+}
+
+const browsePostsForUser = `-- name: BrowsePostsForUser :many
+SELECT
+    posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.guid, posts.content_hash, posts.search_vector, posts.content, posts.author, posts.published_at_source,
+    feeds.name AS feed_name,
+    sort.sort_key,
+    (
+        SELECT COUNT(*)
+        FROM post_revisions
+        WHERE post_revisions.post_id = posts.id
+    ) AS revisions,
+    EXISTS (
+        SELECT 1
+        FROM post_reads
+        WHERE post_reads.post_id = posts.id AND post_reads.user_id = feed_follows.user_id
+    ) AS is_read
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+INNER JOIN feeds ON feeds.id = posts.feed_id
+CROSS JOIN LATERAL (
+    SELECT CASE
+        WHEN $1::TEXT = 'fetched' THEN posts.created_at
+        ELSE posts.published_at
+    END::TIMESTAMP AS sort_key
+) AS sort
+WHERE feed_follows.user_id = $2
+    AND (
+        NOT $3::BOOLEAN
+        OR NOT EXISTS (
+            SELECT 1
+            FROM post_reads
+            WHERE post_reads.post_id = posts.id AND post_reads.user_id = feed_follows.user_id
+        )
+    )
+    AND ($4::UUID IS NULL OR posts.feed_id = $4)
+    AND (
+        $5::TEXT IS NULL
+        OR EXISTS (
+            SELECT 1
+            FROM post_categories
+            INNER JOIN categories ON categories.id = post_categories.category_id
+            WHERE post_categories.post_id = posts.id AND categories.name = LOWER($5)
+        )
+    )
+    AND ($6::TIMESTAMP IS NULL OR sort.sort_key >= $6)
+    AND ($7::TIMESTAMP IS NULL OR sort.sort_key < $7)
+    AND (
+        $8::TIMESTAMP IS NULL
+        OR (sort.sort_key, posts.id) < ($8, $9::UUID)
+    )
+ORDER BY sort.sort_key DESC, posts.id DESC
+LIMIT $11
+OFFSET $10
+`
+
+type BrowsePostsForUserParams struct {
+	SortBy     string
+	UserID     uuid.UUID
+	UnreadOnly bool
+	FeedID     uuid.NullUUID
+	Category   sql.NullString
+	Since      sql.NullTime
+	Until      sql.NullTime
+	CursorKey  sql.NullTime
+	CursorID   uuid.NullUUID
+	Offset     int32
+	Limit      int32
+}
+
+type BrowsePostsForUserRow struct {
+	ID                uuid.UUID
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	Title             string
+	Url               string
+	Description       sql.NullString
+	PublishedAt       time.Time
+	FeedID            uuid.UUID
+	Guid              string
+	ContentHash       string
+	SearchVector      interface{}
+	Content           sql.NullString
+	Author            sql.NullString
+	PublishedAtSource string
+	FeedName          string
+	SortKey           time.Time
+	Revisions         int64
+	IsRead            bool
+}
+
+// Pages through the posts of feeds the user follows, newest first by
+// publication or fetch time. Pagination is by offset or by a cursor made of
+// the last row's sort_key and id.
+func (q *Queries) BrowsePostsForUser(ctx context.Context, arg BrowsePostsForUserParams) ([]BrowsePostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, browsePostsForUser,
+		arg.SortBy,
+		arg.UserID,
+		arg.UnreadOnly,
+		arg.FeedID,
+		arg.Category,
+		arg.Since,
+		arg.Until,
+		arg.CursorKey,
+		arg.CursorID,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BrowsePostsForUserRow
+	for rows.Next() {
+		var i BrowsePostsForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Guid,
+			&i.ContentHash,
+			&i.SearchVector,
+			&i.Content,
+			&i.Author,
+			&i.PublishedAtSource,
+			&i.FeedName,
+			&i.SortKey,
+			&i.Revisions,
+			&i.IsRead,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createPost = `-- name: CreatePost :one
-INSERT INTO
-    posts (
-        id,
-        created_at,
-        updated_at,
-        title,
-        url,
-        description,
-        published_at,
-        feed_id
-    ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id
+WITH previous AS (
+    SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, guid, content_hash, search_vector, content, author, published_at_source
+    FROM posts
+    WHERE posts.feed_id = $1 AND posts.guid = $2
+), upserted AS (
+    INSERT INTO
+        posts (
+            id,
+            created_at,
+            updated_at,
+            title,
+            url,
+            description,
+            published_at,
+            feed_id,
+            guid,
+            content_hash,
+            content,
+            author,
+            published_at_source
+        ) VALUES (
+            $3,
+            $4,
+            $5,
+            $6,
+            $7,
+            $8,
+            $9,
+            $1,
+            $2,
+            $10,
+            $11,
+            $12,
+            $13
+        )
+    ON CONFLICT (feed_id, guid) DO UPDATE
+    SET
+        updated_at = EXCLUDED.updated_at,
+        title = EXCLUDED.title,
+        url = EXCLUDED.url,
+        description = EXCLUDED.description,
+        -- an item without a usable date would otherwise be redated to the
+        -- fetch time on every edit
+        published_at = CASE
+            WHEN EXCLUDED.published_at_source = 'feed' THEN EXCLUDED.published_at
+            ELSE posts.published_at
+        END,
+        published_at_source = CASE
+            WHEN EXCLUDED.published_at_source = 'feed' THEN EXCLUDED.published_at_source
+            ELSE posts.published_at_source
+        END,
+        content_hash = EXCLUDED.content_hash,
+        content = EXCLUDED.content,
+        author = EXCLUDED.author
+    WHERE posts.content_hash <> EXCLUDED.content_hash
+    RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id, guid, content_hash, search_vector, content, author, published_at_source, (xmax = 0)::BOOLEAN AS inserted
+), revision AS (
+    -- keep the version being replaced, unless the update only adds the
+    -- content and author of a post stored before they were
+    INSERT INTO post_revisions (id, post_id, created_at, title, url, description, published_at, content, author)
+    SELECT
+        $14::UUID,
+        previous.id,
+        $5,
+        previous.title,
+        previous.url,
+        previous.description,
+        previous.published_at,
+        previous.content,
+        previous.author
+    FROM previous
+    INNER JOIN upserted ON upserted.id = previous.id
+    WHERE previous.content_hash <> 'legacy:' || $15::TEXT
+)
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, guid, content_hash, search_vector, content, author, published_at_source, inserted FROM upserted
 `
 
 type CreatePostParams struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt time.Time
-	FeedID      uuid.UUID
+	FeedID            uuid.UUID
+	Guid              string
+	ID                uuid.UUID
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	Title             string
+	Url               string
+	Description       sql.NullString
+	PublishedAt       time.Time
+	ContentHash       string
+	Content           sql.NullString
+	Author            sql.NullString
+	PublishedAtSource string
+	RevisionID        uuid.UUID
+	SummaryHash       string
+}
+
+type CreatePostRow struct {
+	ID                uuid.UUID
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	Title             string
+	Url               string
+	Description       sql.NullString
+	PublishedAt       time.Time
+	FeedID            uuid.UUID
+	Guid              string
+	ContentHash       string
+	SearchVector      interface{}
+	Content           sql.NullString
+	Author            sql.NullString
+	PublishedAtSource string
+	Inserted          bool
 }
 
-func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
+func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (CreatePostRow, error) {
 	row := q.db.QueryRowContext(ctx, createPost,
+		arg.FeedID,
+		arg.Guid,
 		arg.ID,
 		arg.CreatedAt,
 		arg.UpdatedAt,
@@ -48,8 +309,67 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		arg.Url,
 		arg.Description,
 		arg.PublishedAt,
-		arg.FeedID,
+		arg.ContentHash,
+		arg.Content,
+		arg.Author,
+		arg.PublishedAtSource,
+		arg.RevisionID,
+		arg.SummaryHash,
+	)
+	var i CreatePostRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.Guid,
+		&i.ContentHash,
+		&i.SearchVector,
+		&i.Content,
+		&i.Author,
+		&i.PublishedAtSource,
+		&i.Inserted,
+	)
+	return i, err
+}
+
+const getLatestPostRevision = `-- name: GetLatestPostRevision :one
+SELECT id, post_id, created_at, title, url, description, published_at, content, author
+FROM post_revisions
+WHERE post_id = $1
+ORDER BY created_at DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLatestPostRevision(ctx context.Context, postID uuid.UUID) (PostRevision, error) {
+	row := q.db.QueryRowContext(ctx, getLatestPostRevision, postID)
+	var i PostRevision
+	err := row.Scan(
+		&i.ID,
+		&i.PostID,
+		&i.CreatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.Content,
+		&i.Author,
 	)
+	return i, err
+}
+
+const getPost = `-- name: GetPost :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, guid, content_hash, search_vector, content, author, published_at_source
+FROM posts
+WHERE id = $1
+`
+
+func (q *Queries) GetPost(ctx context.Context, id uuid.UUID) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPost, id)
 	var i Post
 	err := row.Scan(
 		&i.ID,
@@ -60,42 +380,120 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.Description,
 		&i.PublishedAt,
 		&i.FeedID,
+		&i.Guid,
+		&i.ContentHash,
+		&i.SearchVector,
+		&i.Content,
+		&i.Author,
+		&i.PublishedAtSource,
 	)
 	return i, err
 }
 
-const getPostsForUser = `-- name: GetPostsForUser :many
-SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id
+const movePosts = `-- name: MovePosts :exec
+UPDATE posts
+SET feed_id = $1
+WHERE posts.feed_id = $2
+    AND posts.guid NOT IN (
+        SELECT existing.guid
+        FROM posts existing
+        WHERE existing.feed_id = $1
+    )
+`
+
+type MovePostsParams struct {
+	ToFeedID   uuid.UUID
+	FromFeedID uuid.UUID
+}
+
+// Moves posts to another feed, skipping items it already has.
+func (q *Queries) MovePosts(ctx context.Context, arg MovePostsParams) error {
+	_, err := q.db.ExecContext(ctx, movePosts, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
+
+const searchPostsForUser = `-- name: SearchPostsForUser :many
+SELECT
+    posts.id,
+    posts.title,
+    posts.url,
+    posts.published_at,
+    feeds.name AS feed_name,
+    ts_rank(posts.search_vector, search.query)::FLOAT AS rank,
+    -- descriptions are HTML, highlight their text rather than the markup;
+    -- entities are left for the caller to decode
+    ts_headline(
+        'english',
+        regexp_replace(COALESCE(posts.description, posts.title), '<[^>]*>', ' ', 'g'),
+        search.query,
+        'StartSel=*, StopSel=*, MaxFragments=2, MaxWords=20, MinWords=5'
+    )::TEXT AS snippet
 FROM posts
 INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
-WHERE feed_follows.user_id = $1
-ORDER BY published_at DESC
-LIMIT $2
+INNER JOIN feeds ON feeds.id = posts.feed_id
+CROSS JOIN websearch_to_tsquery('english', $1) AS search (query)
+WHERE feed_follows.user_id = $2
+    AND posts.search_vector @@ search.query
+    AND ($3::UUID IS NULL OR posts.feed_id = $3)
+    AND (
+        $4::TEXT IS NULL
+        OR EXISTS (
+            SELECT 1
+            FROM post_categories
+            INNER JOIN categories ON categories.id = post_categories.category_id
+            WHERE post_categories.post_id = posts.id AND categories.name = LOWER($4)
+        )
+    )
+    AND ($5::TIMESTAMP IS NULL OR posts.published_at >= $5)
+ORDER BY rank DESC, posts.published_at DESC
+LIMIT $6
 `
 
-type GetPostsForUserParams struct {
-	UserID uuid.UUID
-	Limit  int32
+type SearchPostsForUserParams struct {
+	Query          string
+	UserID         uuid.UUID
+	FeedID         uuid.NullUUID
+	Category       sql.NullString
+	PublishedSince sql.NullTime
+	Limit          int32
 }
 
-func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]Post, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Limit)
+type SearchPostsForUserRow struct {
+	ID          uuid.UUID
+	Title       string
+	Url         string
+	PublishedAt time.Time
+	FeedName    string
+	Rank        float64
+	Snippet     string
+}
+
+// Full-text search over the posts of feeds the user follows, best matches
+// first. query accepts web search syntax: quoted phrases, OR and -exclusions.
+func (q *Queries) SearchPostsForUser(ctx context.Context, arg SearchPostsForUserParams) ([]SearchPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchPostsForUser,
+		arg.Query,
+		arg.UserID,
+		arg.FeedID,
+		arg.Category,
+		arg.PublishedSince,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Post
+	var items []SearchPostsForUserRow
 	for rows.Next() {
-		var i Post
+		var i SearchPostsForUserRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
 			&i.Title,
 			&i.Url,
-			&i.Description,
 			&i.PublishedAt,
-			&i.FeedID,
+			&i.FeedName,
+			&i.Rank,
+			&i.Snippet,
 		); err != nil {
 			return nil, err
 		}
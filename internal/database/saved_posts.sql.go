@@ -0,0 +1,122 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: saved_posts.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const getSavedPosts = `-- name: GetSavedPosts :many
+SELECT id, user_id, post_id, created_at, title, url, description, published_at, feed_name
+FROM saved_posts
+WHERE user_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) GetSavedPosts(ctx context.Context, userID uuid.UUID) ([]SavedPost, error) {
+	rows, err := q.db.QueryContext(ctx, getSavedPosts, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SavedPost
+	for rows.Next() {
+		var i SavedPost
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.PostID,
+			&i.CreatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const savePost = `-- name: SavePost :execrows
+INSERT INTO
+    saved_posts (
+        id,
+        user_id,
+        post_id,
+        created_at,
+        title,
+        url,
+        description,
+        published_at,
+        feed_name
+    )
+SELECT
+    $1,
+    $2,
+    posts.id,
+    $3,
+    posts.title,
+    posts.url,
+    posts.description,
+    posts.published_at,
+    feeds.name
+FROM posts
+INNER JOIN feeds ON feeds.id = posts.feed_id
+WHERE posts.id = $4
+ON CONFLICT (user_id, post_id) DO NOTHING
+`
+
+type SavePostParams struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+	PostID    uuid.UUID
+}
+
+func (q *Queries) SavePost(ctx context.Context, arg SavePostParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, savePost,
+		arg.ID,
+		arg.UserID,
+		arg.CreatedAt,
+		arg.PostID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const unsavePost = `-- name: UnsavePost :execrows
+DELETE FROM saved_posts
+WHERE user_id = $1
+    AND (id = $2 OR post_id = $2)
+`
+
+type UnsavePostParams struct {
+	UserID uuid.UUID
+	ID     uuid.UUID
+}
+
+// Accepts either the saved post's own id or the id of the original post.
+func (q *Queries) UnsavePost(ctx context.Context, arg UnsavePostParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, unsavePost, arg.UserID, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,225 @@
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"gator/internal/redirect"
+	"gator/internal/rss"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// maxBodySize bounds how much of a page or candidate feed is read.
+const maxBodySize = 10 << 20
+
+// feedTypes are the <link type> values that advertise a feed.
+var feedTypes = map[string]bool{
+	"application/rss+xml":   true,
+	"application/atom+xml":  true,
+	"application/rdf+xml":   true,
+	"application/feed+json": true,
+}
+
+// commonPaths are probed, in order, when a page advertises no feed.
+var commonPaths = []string{
+	"feed",
+	"rss",
+	"feed.xml",
+	"rss.xml",
+	"atom.xml",
+	"index.xml",
+	"feed.json",
+}
+
+var ErrNoFeed = errors.New("no feed found")
+
+// Candidate is a feed found for a page.
+type Candidate struct {
+	URL   string
+	Title string
+	Type  string
+}
+
+// Discover returns the feeds for pageURL. A URL that already points at a feed
+// is returned as the only candidate. For HTML pages the <link rel="alternate">
+// tags are used, falling back to probing common feed paths.
+func Discover(ctx context.Context, client *http.Client, pageURL string) ([]Candidate, error) {
+	page, err := get(ctx, client, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// feeds are tried first as servers often label them text/html
+	if candidate, ok := page.feed(); ok {
+		return []Candidate{candidate}, nil
+	}
+	if !isHTML(page.body, page.contentType) {
+		return nil, fmt.Errorf("%s is neither a feed nor an HTML page", pageURL)
+	}
+
+	candidates := parseLinks(page.body, page.url)
+	if len(candidates) > 0 {
+		return candidates, nil
+	}
+
+	for _, probe := range probeURLs(page.url) {
+		res, err := get(ctx, client, probe)
+		if err != nil {
+			continue
+		}
+		if candidate, ok := res.feed(); ok {
+			return []Candidate{candidate}, nil
+		}
+	}
+
+	return nil, ErrNoFeed
+}
+
+type response struct {
+	body        []byte
+	contentType string
+	// url is where the body was served from, for resolving relative links
+	url *url.URL
+	// canonical is the URL to keep: the requested one unless every redirect
+	// on the way was permanent
+	canonical string
+}
+
+// feed returns the response as a candidate if it parses as a feed.
+func (r *response) feed() (Candidate, bool) {
+	feed, err := rss.Parse(bytes.NewReader(r.body), r.contentType)
+	if err != nil {
+		return Candidate{}, false
+	}
+	return Candidate{URL: r.canonical, Title: feed.Channel.Title, Type: r.contentType}, true
+}
+
+func get(ctx context.Context, client *http.Client, rawURL string) (*response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "gator")
+
+	client, redirects := redirect.Track(client)
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: unexpected status: %s", rawURL, res.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxBodySize))
+	if err != nil {
+		return nil, err
+	}
+	canonical := rawURL
+	if movedTo := redirects.MovedTo(); movedTo != "" {
+		canonical = movedTo
+	}
+	return &response{
+		body:        body,
+		contentType: res.Header.Get("Content-Type"),
+		url:         res.Request.URL,
+		canonical:   canonical,
+	}, nil
+}
+
+func isHTML(body []byte, contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "text/html" || mediaType == "application/xhtml+xml" {
+		return true
+	}
+	return strings.HasPrefix(http.DetectContentType(body), "text/html")
+}
+
+// parseLinks collects the feeds advertised by <link rel="alternate"> tags,
+// resolving their hrefs against the page URL or its <base>.
+func parseLinks(body []byte, base *url.URL) []Candidate {
+	var candidates []Candidate
+	seen := make(map[string]bool)
+
+	z := html.NewTokenizer(bytes.NewReader(body))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return candidates
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			switch tok.Data {
+			case "base":
+				if href, err := base.Parse(attr(tok, "href")); err == nil {
+					base = href
+				}
+			case "link":
+				linkType := strings.ToLower(attr(tok, "type"))
+				if !isAlternate(attr(tok, "rel")) || !feedTypes[linkType] {
+					continue
+				}
+				href, err := base.Parse(strings.TrimSpace(attr(tok, "href")))
+				if err != nil || seen[href.String()] {
+					continue
+				}
+				seen[href.String()] = true
+				candidates = append(candidates, Candidate{
+					URL:   href.String(),
+					Title: attr(tok, "title"),
+					Type:  linkType,
+				})
+			}
+		}
+	}
+}
+
+func isAlternate(rel string) bool {
+	for _, r := range strings.Fields(strings.ToLower(rel)) {
+		if r == "alternate" {
+			return true
+		}
+	}
+	return false
+}
+
+func attr(tok html.Token, name string) string {
+	for _, a := range tok.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// probeURLs returns the common feed locations below the page and at the root
+// of its site.
+func probeURLs(page *url.URL) []string {
+	var urls []string
+	seen := make(map[string]bool)
+	add := func(ref string) {
+		u, err := page.Parse(ref)
+		if err != nil || seen[u.String()] {
+			return
+		}
+		seen[u.String()] = true
+		urls = append(urls, u.String())
+	}
+
+	if strings.HasSuffix(page.Path, "/") {
+		for _, p := range commonPaths {
+			add(p)
+		}
+	}
+	for _, p := range commonPaths {
+		add("/" + p)
+	}
+	return urls
+}
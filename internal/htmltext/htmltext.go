@@ -0,0 +1,386 @@
+// Package htmltext renders the HTML found in feeds as plain text for a
+// terminal: paragraphs are wrapped, lists get bullets, links become numbered
+// footnotes and images are replaced by their alt text.
+package htmltext
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// minWidth keeps deeply nested text readable on narrow terminals.
+const minWidth = 20
+
+// skipped elements are dropped along with their content.
+var skipped = map[atom.Atom]bool{
+	atom.Head:     true,
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Iframe:   true,
+	atom.Object:   true,
+	atom.Svg:      true,
+	atom.Button:   true,
+	atom.Form:     true,
+}
+
+// blocks start a new paragraph.
+var blocks = map[atom.Atom]bool{
+	atom.P:          true,
+	atom.Div:        true,
+	atom.Section:    true,
+	atom.Article:    true,
+	atom.Header:     true,
+	atom.Footer:     true,
+	atom.Aside:      true,
+	atom.Main:       true,
+	atom.Nav:        true,
+	atom.Figure:     true,
+	atom.Figcaption: true,
+	atom.Details:    true,
+	atom.Summary:    true,
+	atom.Dl:         true,
+	atom.Dt:         true,
+	atom.Dd:         true,
+	atom.Table:      true,
+	atom.Address:    true,
+}
+
+var headings = map[atom.Atom]int{
+	atom.H1: 1, atom.H2: 2, atom.H3: 3, atom.H4: 4, atom.H5: 5, atom.H6: 6,
+}
+
+type renderer struct {
+	width int
+	base  *url.URL
+
+	lines []string
+	// text is the inline content of the paragraph being built, "\n" marking
+	// a <br>
+	text strings.Builder
+	// indent holds the prefix each enclosing block adds to its lines
+	indent []string
+	// marker replaces the innermost indent on the next line written, for
+	// list bullets
+	marker string
+	// blank requests an empty line before the next paragraph
+	blank bool
+	links []string
+	// counters number the items of the enclosing lists, 0 for bullets
+	counters []int
+}
+
+// Render converts an HTML fragment to text wrapped at width columns. Relative
+// links and images are resolved against base, which may be nil.
+func Render(content string, width int, base *url.URL) string {
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		// the parser accepts any input, but keep the text readable if not
+		return Printable(content)
+	}
+
+	r := &renderer{width: max(width, minWidth), base: base}
+	r.walk(doc)
+	r.flush()
+
+	if len(r.links) > 0 {
+		r.lines = append(r.lines, "")
+		for i, link := range r.links {
+			r.lines = append(r.lines, fmt.Sprintf("[%d] %s", i+1, link))
+		}
+	}
+	// text, alt text and links all come from the feed
+	return Printable(strings.Join(r.lines, "\n"))
+}
+
+// Printable replaces control characters other than newlines with spaces, so
+// text from a feed can't send escape sequences to the terminal. Each rune stays one
+// rune, keeping the wrapping intact.
+func Printable(s string) string {
+	return strings.Map(func(r rune) rune {
+		if (r < 0x20 && r != '\n') || r == 0x7f || (r >= 0x80 && r < 0xa0) {
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+func (r *renderer) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		r.text.WriteString(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		r.children(n)
+		return
+	}
+
+	if skipped[n.DataAtom] {
+		return
+	}
+	if level, ok := headings[n.DataAtom]; ok {
+		r.block()
+		r.text.WriteString(strings.Repeat("#", level) + " ")
+		r.children(n)
+		r.block()
+		return
+	}
+	if blocks[n.DataAtom] {
+		r.block()
+		r.children(n)
+		r.block()
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		r.text.WriteString("\n")
+	case atom.Hr:
+		r.block()
+		r.write(strings.Repeat("─", min(r.available(), 40)))
+		r.blank = true
+	case atom.Img:
+		alt := strings.TrimSpace(attr(n, "alt"))
+		if alt == "" {
+			alt = "image"
+		}
+		r.text.WriteString("[" + alt + "]")
+	case atom.A:
+		r.children(n)
+		r.link(attr(n, "href"), strings.TrimSpace(textContent(n)))
+	case atom.Code, atom.Kbd, atom.Samp:
+		r.text.WriteString("`")
+		r.children(n)
+		r.text.WriteString("`")
+	case atom.Pre:
+		r.pre(n)
+	case atom.Blockquote:
+		r.block()
+		// the line before the quote isn't part of it
+		r.separate()
+		r.indent = append(r.indent, "> ")
+		r.children(n)
+		r.flush()
+		r.indent = r.indent[:len(r.indent)-1]
+		r.blank = true
+	case atom.Ul, atom.Ol:
+		r.list(n)
+	case atom.Li:
+		r.item(n)
+	case atom.Tr:
+		r.flush()
+		r.children(n)
+		r.flush()
+	case atom.Td, atom.Th:
+		if n.PrevSibling != nil {
+			r.text.WriteString(" | ")
+		}
+		r.children(n)
+	default:
+		r.children(n)
+	}
+}
+
+func (r *renderer) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r.walk(c)
+	}
+}
+
+// block ends the current paragraph and separates it from the next one.
+func (r *renderer) block() {
+	r.flush()
+	r.blank = true
+}
+
+func (r *renderer) list(n *html.Node) {
+	// nested lists stay tight
+	nested := len(r.counters) > 0
+	if nested {
+		r.flush()
+	} else {
+		r.block()
+	}
+	counter := 0
+	if n.DataAtom == atom.Ol {
+		counter = 1
+		if _, err := fmt.Sscan(attr(n, "start"), &counter); err != nil {
+			counter = 1
+		}
+	}
+	r.counters = append(r.counters, counter)
+	r.children(n)
+	r.flush()
+	r.counters = r.counters[:len(r.counters)-1]
+	r.blank = !nested
+}
+
+func (r *renderer) item(n *html.Node) {
+	r.flush()
+	// an item holding only a nested list still gets its own bullet
+	if r.marker != "" {
+		r.write("")
+	}
+
+	r.marker = "• "
+	if depth := len(r.counters); depth > 0 && r.counters[depth-1] > 0 {
+		r.marker = fmt.Sprintf("%d. ", r.counters[depth-1])
+		r.counters[depth-1]++
+	}
+	r.indent = append(r.indent, strings.Repeat(" ", utf8.RuneCountInString(r.marker)))
+	r.children(n)
+	r.flush()
+	r.indent = r.indent[:len(r.indent)-1]
+	r.marker = ""
+}
+
+// pre writes preformatted text as is, indented and never wrapped.
+func (r *renderer) pre(n *html.Node) {
+	r.block()
+	r.indent = append(r.indent, "    ")
+	for _, line := range strings.Split(strings.Trim(textContent(n), "\n"), "\n") {
+		r.write(strings.ReplaceAll(strings.TrimRight(line, " \t\r"), "\t", "    "))
+	}
+	r.indent = r.indent[:len(r.indent)-1]
+	r.blank = true
+}
+
+// link adds href as a footnote unless it is a fragment, empty, or already
+// spelled out by the link text. The same target keeps its number.
+func (r *renderer) link(href, text string) {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(href, "javascript:") {
+		return
+	}
+	if r.base != nil {
+		if u, err := r.base.Parse(href); err == nil {
+			href = u.String()
+		}
+	}
+	if text == href || "mailto:"+text == href {
+		return
+	}
+	for i, link := range r.links {
+		if link == href {
+			fmt.Fprintf(&r.text, "[%d]", i+1)
+			return
+		}
+	}
+	r.links = append(r.links, href)
+	fmt.Fprintf(&r.text, "[%d]", len(r.links))
+}
+
+// flush wraps the pending inline text into lines.
+func (r *renderer) flush() {
+	raw := r.text.String()
+	r.text.Reset()
+
+	var words [][]string
+	for _, line := range strings.Split(raw, "\n") {
+		words = append(words, strings.Fields(line))
+	}
+	// drop empty lines at either end, from <br>s next to block edges
+	for len(words) > 0 && len(words[0]) == 0 {
+		words = words[1:]
+	}
+	for len(words) > 0 && len(words[len(words)-1]) == 0 {
+		words = words[:len(words)-1]
+	}
+	if len(words) == 0 {
+		return
+	}
+
+	for _, line := range words {
+		if len(line) == 0 {
+			r.write("")
+		}
+		for _, wrapped := range wrap(line, r.available()) {
+			r.write(wrapped)
+		}
+	}
+}
+
+// available is the width left after the indentation.
+func (r *renderer) available() int {
+	return max(r.width-utf8.RuneCountInString(strings.Join(r.indent, "")), minWidth)
+}
+
+// write adds a line with the current indentation.
+func (r *renderer) write(line string) {
+	r.separate()
+
+	prefix := strings.Join(r.indent, "")
+	if r.marker != "" {
+		prefix = strings.Join(r.indent[:len(r.indent)-1], "") + r.marker
+		r.marker = ""
+	}
+	r.lines = append(r.lines, strings.TrimRight(prefix+line, " "))
+}
+
+// separate writes the empty line requested by blank, unless the last line
+// is already empty but for quote markers.
+func (r *renderer) separate() {
+	if r.blank && len(r.lines) > 0 && strings.Trim(r.lines[len(r.lines)-1], "> ") != "" {
+		r.lines = append(r.lines, strings.TrimRight(strings.Join(r.indent, ""), " "))
+	}
+	r.blank = false
+}
+
+// wrap joins words into lines of at most width runes, breaking words that
+// are longer than a line.
+func wrap(words []string, width int) []string {
+	var lines []string
+	var line strings.Builder
+	n := 0
+	for _, word := range words {
+		wordLen := utf8.RuneCountInString(word)
+		if n > 0 && n+1+wordLen > width {
+			lines = append(lines, line.String())
+			line.Reset()
+			n = 0
+		}
+		for wordLen > width {
+			runes := []rune(word)
+			lines = append(lines, string(runes[:width]))
+			word = string(runes[width:])
+			wordLen -= width
+		}
+		if n > 0 {
+			line.WriteString(" ")
+			n++
+		}
+		line.WriteString(word)
+		n += wordLen
+	}
+	if n > 0 {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textContent(c))
+	}
+	return b.String()
+}
+
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,114 @@
+package opml
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+type OPML struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    Head     `xml:"head"`
+	Body    Body     `xml:"body"`
+}
+
+type Head struct {
+	Title       string `xml:"title,omitempty"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+	OwnerName   string `xml:"ownerName,omitempty"`
+}
+
+type Body struct {
+	Outlines []Outline `xml:"outline"`
+}
+
+// Outline is either a subscription, when XMLURL is set, or a folder holding
+// further outlines.
+type Outline struct {
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr,omitempty"`
+	Type     string    `xml:"type,attr,omitempty"`
+	XMLURL   string    `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string    `xml:"htmlUrl,attr,omitempty"`
+	Outlines []Outline `xml:"outline"`
+}
+
+// UnmarshalXML reads attributes case-insensitively, since OPML 1.0 exporters
+// disagree on spelling ("xmlUrl", "xmlurl", "XMLURL").
+func (o *Outline) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, a := range start.Attr {
+		switch strings.ToLower(a.Name.Local) {
+		case "text":
+			o.Text = a.Value
+		case "title":
+			o.Title = a.Value
+		case "type":
+			o.Type = a.Value
+		case "xmlurl":
+			o.XMLURL = strings.TrimSpace(a.Value)
+		case "htmlurl":
+			o.HTMLURL = strings.TrimSpace(a.Value)
+		}
+	}
+
+	var children struct {
+		Outlines []Outline `xml:"outline"`
+	}
+	if err := d.DecodeElement(&children, &start); err != nil {
+		return err
+	}
+	o.Outlines = children.Outlines
+	return nil
+}
+
+// Subscription is a feed outline flattened out of its folders.
+type Subscription struct {
+	Title   string
+	XMLURL  string
+	HTMLURL string
+	// Folder is the path of enclosing folder outlines joined by "/", empty at
+	// the top level.
+	Folder string
+}
+
+func Parse(r io.Reader) (*OPML, error) {
+	var doc OPML
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// Subscriptions returns every feed outline in document order. Outlines with
+// neither a feed URL nor children are skipped.
+func (doc *OPML) Subscriptions() []Subscription {
+	var subs []Subscription
+	var walk func(outlines []Outline, folder string)
+	walk = func(outlines []Outline, folder string) {
+		for _, o := range outlines {
+			title := o.Title
+			if title == "" {
+				title = o.Text
+			}
+
+			if o.XMLURL != "" || o.Type == "rss" {
+				subs = append(subs, Subscription{
+					Title:   title,
+					XMLURL:  o.XMLURL,
+					HTMLURL: o.HTMLURL,
+					Folder:  folder,
+				})
+				continue
+			}
+
+			sub := title
+			if folder != "" {
+				sub = folder + "/" + title
+			}
+			walk(o.Outlines, sub)
+		}
+	}
+	walk(doc.Body.Outlines, "")
+	return subs
+}
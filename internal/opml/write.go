@@ -0,0 +1,83 @@
+package opml
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"time"
+)
+
+// New builds an OPML 2.0 document from subscriptions, nesting each one under
+// folder outlines following its Folder path.
+func New(title string, subs []Subscription) *OPML {
+	root := &folder{}
+	for _, sub := range subs {
+		f := root
+		if sub.Folder != "" {
+			for _, name := range strings.Split(sub.Folder, "/") {
+				f = f.child(name)
+			}
+		}
+		f.feeds = append(f.feeds, Outline{
+			Text:    sub.Title,
+			Title:   sub.Title,
+			Type:    "rss",
+			XMLURL:  sub.XMLURL,
+			HTMLURL: sub.HTMLURL,
+		})
+	}
+
+	return &OPML{
+		Version: "2.0",
+		Head: Head{
+			Title:       title,
+			DateCreated: time.Now().Format(time.RFC1123Z),
+		},
+		Body: Body{Outlines: root.outlines()},
+	}
+}
+
+// Write encodes the document with an XML declaration.
+func (doc *OPML) Write(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+type folder struct {
+	name    string
+	feeds   []Outline
+	folders []*folder
+}
+
+func (f *folder) child(name string) *folder {
+	for _, c := range f.folders {
+		if c.name == name {
+			return c
+		}
+	}
+	c := &folder{name: name}
+	f.folders = append(f.folders, c)
+	return c
+}
+
+// outlines returns the folder's subfolders, in the order first seen,
+// followed by its feeds.
+func (f *folder) outlines() []Outline {
+	var outlines []Outline
+	for _, c := range f.folders {
+		outlines = append(outlines, Outline{
+			Text:     c.name,
+			Title:    c.name,
+			Outlines: c.outlines(),
+		})
+	}
+	return append(outlines, f.feeds...)
+}
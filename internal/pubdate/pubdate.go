@@ -0,0 +1,177 @@
+package pubdate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Source records where a publication date came from.
+type Source int
+
+const (
+	// SourceFeed means the date was parsed from the feed item.
+	SourceFeed Source = iota
+	// SourceMissing means the item had no date and the fallback was used.
+	SourceMissing
+	// SourceInvalid means the item's date could not be parsed and the
+	// fallback was used.
+	SourceInvalid
+)
+
+func (s Source) String() string {
+	switch s {
+	case SourceFeed:
+		return "feed"
+	case SourceMissing:
+		return "missing"
+	case SourceInvalid:
+		return "invalid"
+	default:
+		return fmt.Sprintf("Source(%d)", int(s))
+	}
+}
+
+// layouts are tried in order, most common first. Zone abbreviations and
+// leading weekdays are normalized away before parsing, so only numeric
+// offsets and zoneless forms need to be listed.
+var layouts = []string{
+	// RFC 822 / 1123 and the usual deviations from it; "2" also accepts
+	// zero-padded days
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 -07:00",
+	"2 Jan 2006 15:04 -0700",
+	"2 Jan 06 15:04:05 -0700",
+	"2 Jan 06 15:04 -0700",
+	"2 January 2006 15:04:05 -0700",
+	"2 January 2006 15:04 -0700",
+	"2 Jan 2006 15:04:05",
+	"2 Jan 2006",
+	// RFC 850
+	"2-Jan-06 15:04:05 -0700",
+	"2-Jan-2006 15:04:05 -0700",
+	// RFC 3339 / ISO 8601
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02T15:04:05 -0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	// ctime style, as emitted by some hand-rolled generators
+	"Jan 2 15:04:05 2006",
+	"Jan 2 2006 15:04:05 -0700",
+	"January 2, 2006",
+}
+
+// zones maps the named zones allowed by RFC 822 plus a few that show up in
+// the wild to their offsets. time.Parse gives unknown abbreviations a zero
+// offset, so these are rewritten to numeric offsets up front.
+var zones = map[string]string{
+	"UT":   "+0000",
+	"UTC":  "+0000",
+	"GMT":  "+0000",
+	"Z":    "+0000",
+	"EST":  "-0500",
+	"EDT":  "-0400",
+	"CST":  "-0600",
+	"CDT":  "-0500",
+	"MST":  "-0700",
+	"MDT":  "-0600",
+	"PST":  "-0800",
+	"PDT":  "-0700",
+	"AKST": "-0900",
+	"AKDT": "-0800",
+	"HST":  "-1000",
+	"BST":  "+0100",
+	"IST":  "+0530",
+	"CET":  "+0100",
+	"CEST": "+0200",
+	"EET":  "+0200",
+	"EEST": "+0300",
+	"MSK":  "+0300",
+	"JST":  "+0900",
+	"KST":  "+0900",
+	"AEST": "+1000",
+	"AEDT": "+1100",
+	"NZST": "+1200",
+	"NZDT": "+1300",
+}
+
+var errEmpty = errors.New("pubdate: empty date")
+
+// Parse parses a feed publication date in any of the supported layouts.
+// Dates without a zone are assumed to be UTC. The result is always in UTC:
+// published_at has no zone, so an offset would be dropped when storing it.
+func Parse(value string) (time.Time, error) {
+	normalized := normalize(value)
+	if normalized == "" {
+		return time.Time{}, errEmpty
+	}
+
+	for _, layout := range layouts {
+		t, err := time.Parse(layout, normalized)
+		if err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("pubdate: unrecognised date %q", value)
+}
+
+// ParseOr parses value like Parse, returning fallback when the date is absent
+// or unparseable along with the Source saying which case applied.
+func ParseOr(value string, fallback time.Time) (time.Time, Source) {
+	t, err := Parse(value)
+	switch {
+	case err == errEmpty:
+		return fallback, SourceMissing
+	case err != nil:
+		return fallback, SourceInvalid
+	default:
+		return t, SourceFeed
+	}
+}
+
+func normalize(value string) string {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	// weekdays are redundant and frequently misspelled ("Tues", "Thur")
+	if strings.HasSuffix(fields[0], ",") || isWeekday(fields[0]) {
+		fields = fields[1:]
+	}
+
+	// drop trailing comments such as "-0800 (PST)"
+	if n := len(fields); n > 0 && strings.HasPrefix(fields[n-1], "(") {
+		fields = fields[:n-1]
+	}
+
+	if n := len(fields); n > 0 {
+		last := strings.ToUpper(fields[n-1])
+		if offset, ok := zones[last]; ok {
+			fields[n-1] = offset
+		} else if strings.HasPrefix(last, "GMT") || strings.HasPrefix(last, "UTC") {
+			// "GMT+0200"
+			fields[n-1] = last[3:]
+		}
+	}
+
+	return strings.Join(fields, " ")
+}
+
+func isWeekday(s string) bool {
+	s = strings.ToLower(strings.TrimSuffix(s, ","))
+	for _, day := range []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"} {
+		if strings.HasPrefix(s, day) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package pubdate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		// RFC 822 / 1123
+		{"rfc1123", "Mon, 02 Jan 2006 15:04:05 GMT", "2006-01-02T15:04:05Z"},
+		{"rfc1123z", "Mon, 02 Jan 2006 15:04:05 -0700", "2006-01-02T22:04:05Z"},
+		{"colon offset", "Mon, 02 Jan 2006 15:04:05 +00:00", "2006-01-02T15:04:05Z"},
+		{"no weekday", "02 Jan 2006 15:04:05 +0000", "2006-01-02T15:04:05Z"},
+		{"misspelled weekday", "Tues, 03 Jan 2006 15:04:05 +0000", "2006-01-03T15:04:05Z"},
+		{"single digit day", "Mon, 2 Jan 2006 15:04:05 +0000", "2006-01-02T15:04:05Z"},
+		{"missing seconds", "Mon, 02 Jan 2006 15:04 +0000", "2006-01-02T15:04:00Z"},
+		{"two digit year", "Mon, 02 Jan 06 15:04:05 +0000", "2006-01-02T15:04:05Z"},
+		{"long month", "2 January 2006 15:04:05 +0000", "2006-01-02T15:04:05Z"},
+		{"trailing comment", "Mon, 02 Jan 2006 15:04:05 -0800 (PST)", "2006-01-02T23:04:05Z"},
+		{"surrounding space", "  Mon, 02 Jan 2006 15:04:05 GMT\n", "2006-01-02T15:04:05Z"},
+
+		// named zones
+		{"ut", "Mon, 02 Jan 2006 15:04:05 UT", "2006-01-02T15:04:05Z"},
+		{"est", "Mon, 02 Jan 2006 15:04:05 EST", "2006-01-02T20:04:05Z"},
+		{"pdt", "Mon, 02 Jan 2006 15:04:05 PDT", "2006-01-02T22:04:05Z"},
+		{"lower case zone", "Mon, 02 Jan 2006 15:04:05 cest", "2006-01-02T13:04:05Z"},
+		{"gmt offset", "Mon, 02 Jan 2006 15:04:05 GMT+0200", "2006-01-02T13:04:05Z"},
+		{"no zone", "02 Jan 2006 15:04:05", "2006-01-02T15:04:05Z"},
+		{"date only", "02 Jan 2006", "2006-01-02T00:00:00Z"},
+
+		// RFC 850
+		{"rfc850", "Monday, 02-Jan-06 15:04:05 GMT", "2006-01-02T15:04:05Z"},
+
+		// RFC 3339 / ISO 8601
+		{"rfc3339", "2006-01-02T15:04:05Z", "2006-01-02T15:04:05Z"},
+		{"rfc3339 offset", "2006-01-02T15:04:05+02:00", "2006-01-02T13:04:05Z"},
+		{"rfc3339 nano", "2006-01-02T15:04:05.123456789Z", "2006-01-02T15:04:05.123456789Z"},
+		{"rfc3339 missing seconds", "2006-01-02T15:04Z", "2006-01-02T15:04:00Z"},
+		{"iso compact offset", "2006-01-02T15:04:05-0500", "2006-01-02T20:04:05Z"},
+		{"iso no zone", "2006-01-02T15:04:05", "2006-01-02T15:04:05Z"},
+		{"space separated", "2006-01-02 15:04:05 +0100", "2006-01-02T14:04:05Z"},
+		{"iso date", "2006-01-02", "2006-01-02T00:00:00Z"},
+
+		// others seen in the wild
+		{"ctime", "Mon Jan 2 15:04:05 2006", "2006-01-02T15:04:05Z"},
+		{"us long date", "January 2, 2006", "2006-01-02T00:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.value)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.value, err)
+			}
+			if got.Location() != time.UTC {
+				t.Errorf("Parse(%q) location = %v, want UTC", tt.value, got.Location())
+			}
+			if s := got.Format(time.RFC3339Nano); s != tt.want {
+				t.Errorf("Parse(%q) = %s, want %s", tt.value, s, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		empty bool
+	}{
+		{"empty", "", true},
+		{"blank", " \t\n", true},
+		{"garbage", "not a date", false},
+		{"weekday only", "Monday,", true},
+		{"bad month", "02 Foo 2006 15:04:05 GMT", false},
+		{"bad day", "32 Jan 2006 15:04:05 GMT", false},
+		{"unknown zone", "Mon, 02 Jan 2006 15:04:05 XYZ", false},
+		{"unix timestamp", "1136214245", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.value)
+			if err == nil {
+				t.Fatalf("Parse(%q) succeeded, want an error", tt.value)
+			}
+			if (err == errEmpty) != tt.empty {
+				t.Errorf("Parse(%q) = %v, empty = %v", tt.value, err, tt.empty)
+			}
+		})
+	}
+}
+
+func TestParseOr(t *testing.T) {
+	fallback := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	tests := []struct {
+		value  string
+		want   time.Time
+		source Source
+	}{
+		{"Mon, 02 Jan 2006 15:04:05 GMT", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC), SourceFeed},
+		{"", fallback, SourceMissing},
+		{"yesterday", fallback, SourceInvalid},
+	}
+	for _, tt := range tests {
+		got, source := ParseOr(tt.value, fallback)
+		if !got.Equal(tt.want) || source != tt.source {
+			t.Errorf("ParseOr(%q) = %v, %v, want %v, %v", tt.value, got, source, tt.want, tt.source)
+		}
+	}
+}
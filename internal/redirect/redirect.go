@@ -0,0 +1,44 @@
+// Package redirect tells whether the redirects an HTTP client followed mean
+// a resource has moved for good, so a stored URL can be updated.
+package redirect
+
+import (
+	"errors"
+	"net/http"
+)
+
+// maxRedirects matches the limit of the default http.Client policy.
+const maxRedirects = 10
+
+// Tracker records the redirects followed for one request.
+type Tracker struct {
+	movedTo   string
+	permanent bool
+}
+
+// Track returns a copy of client that records its redirects in the returned
+// Tracker. Use it for a single request.
+func Track(client *http.Client) (*http.Client, *Tracker) {
+	t := &Tracker{permanent: true}
+	c := *client
+	c.CheckRedirect = func(next *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return errors.New("stopped after 10 redirects")
+		}
+		status := next.Response.StatusCode
+		t.permanent = t.permanent && (status == http.StatusMovedPermanently || status == http.StatusPermanentRedirect)
+		t.movedTo = ""
+		if t.permanent {
+			t.movedTo = next.URL.String()
+		}
+		return nil
+	}
+	return &c, t
+}
+
+// MovedTo returns the final URL when there were redirects and every one was
+// a 301 or 308. A single temporary hop anywhere in the chain means the
+// resource didn't move, and "" is returned.
+func (t *Tracker) MovedTo() string {
+	return t.movedTo
+}
@@ -0,0 +1,67 @@
+package redirect
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTrack(t *testing.T) {
+	// each path redirects with the status it is named after, to the first
+	// path in its "to" parameters, passing on the rest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		chain := r.URL.Query()["to"]
+		if len(chain) == 0 {
+			return
+		}
+		var status int
+		switch r.URL.Path {
+		case "/301":
+			status = http.StatusMovedPermanently
+		case "/302":
+			status = http.StatusFound
+		case "/307":
+			status = http.StatusTemporaryRedirect
+		case "/308":
+			status = http.StatusPermanentRedirect
+		}
+		next := "/" + chain[0]
+		if len(chain) > 1 {
+			next += "?to=" + strings.Join(chain[1:], "&to=")
+		}
+		http.Redirect(w, r, next, status)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name  string
+		path  string
+		moved string
+	}{
+		{"no redirect", "/end", ""},
+		{"permanent", "/301?to=end", "/end"},
+		{"permanent chain", "/301?to=308&to=end", "/end"},
+		{"temporary", "/302?to=end", ""},
+		{"temporary after permanent", "/301?to=302&to=end", ""},
+		{"permanent after temporary", "/307?to=301&to=end", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, redirects := Track(http.DefaultClient)
+			res, err := client.Get(srv.URL + tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+
+			want := tt.moved
+			if want != "" {
+				want = srv.URL + want
+			}
+			if got := redirects.MovedTo(); got != want {
+				t.Errorf("MovedTo() = %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package render
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+type Format string
+
+const (
+	Table  Format = "table"
+	JSON   Format = "json"
+	NDJSON Format = "ndjson"
+	CSV    Format = "csv"
+)
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case Table, JSON, NDJSON, CSV:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown output format '%s', expected table, json, ndjson or csv", s)
+	}
+}
+
+// Writer renders rows made of a fixed list of named fields. Field names are
+// used as JSON keys and CSV headers, so they are part of each command's
+// output contract.
+//
+// Values may be strings, numbers, bools, time.Time, nil for missing values, or
+// driver.Valuers such as sql.NullString and uuid.UUID, which are rendered as
+// the value they store.
+type Writer struct {
+	format Format
+	out    io.Writer
+	fields []string
+	rows   [][]any
+}
+
+func NewWriter(out io.Writer, format Format, fields ...string) *Writer {
+	return &Writer{
+		format: format,
+		out:    out,
+		fields: fields,
+	}
+}
+
+// Row adds a row with one value per field. NDJSON rows are written
+// immediately, the other formats are written by Flush.
+func (w *Writer) Row(values ...any) error {
+	if len(values) != len(w.fields) {
+		return fmt.Errorf("render: got %d values for %d fields", len(values), len(w.fields))
+	}
+	for i, v := range values {
+		if valuer, ok := v.(driver.Valuer); ok {
+			value, err := valuer.Value()
+			if err != nil {
+				return fmt.Errorf("render: field %s: %w", w.fields[i], err)
+			}
+			values[i] = value
+		}
+	}
+	if w.format == NDJSON {
+		line, err := w.object(values)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w.out, "%s\n", line)
+		return err
+	}
+	w.rows = append(w.rows, values)
+	return nil
+}
+
+func (w *Writer) Flush() error {
+	switch w.format {
+	case NDJSON:
+		return nil
+	case JSON:
+		return w.flushJSON()
+	case CSV:
+		return w.flushCSV()
+	default:
+		return w.flushTable()
+	}
+}
+
+func (w *Writer) flushJSON() error {
+	var buf bytes.Buffer
+	buf.WriteString("[")
+	for i, row := range w.rows {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n  ")
+		obj, err := w.object(row)
+		if err != nil {
+			return err
+		}
+		buf.Write(obj)
+	}
+	if len(w.rows) > 0 {
+		buf.WriteString("\n")
+	}
+	buf.WriteString("]\n")
+	_, err := w.out.Write(buf.Bytes())
+	return err
+}
+
+// object encodes a row as a JSON object, keeping the field order.
+func (w *Writer) object(values []any) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	for i, field := range w.fields {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		key, _ := json.Marshal(field)
+		value, err := json.Marshal(values[i])
+		if err != nil {
+			return nil, fmt.Errorf("render: field %s: %w", field, err)
+		}
+		buf.Write(key)
+		buf.WriteString(":")
+		buf.Write(value)
+	}
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
+
+func (w *Writer) flushCSV() error {
+	cw := csv.NewWriter(w.out)
+	if err := cw.Write(w.fields); err != nil {
+		return err
+	}
+	for _, row := range w.rows {
+		record := make([]string, len(row))
+		for i, v := range row {
+			record[i] = text(v, time.RFC3339)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (w *Writer) flushTable() error {
+	tw := tabwriter.NewWriter(w.out, 0, 0, 2, ' ', 0)
+	header := make([]string, len(w.fields))
+	for i, field := range w.fields {
+		header[i] = strings.ToUpper(field)
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range w.rows {
+		cells := make([]string, len(row))
+		for i, v := range row {
+			// tabs and newlines would break the column layout
+			cells[i] = strings.Join(strings.Fields(printable(text(v, time.DateTime))), " ")
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+// printable replaces control characters with spaces, so values from feeds
+// can't send escape sequences to the terminal.
+func printable(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || (r >= 0x80 && r < 0xa0) {
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+// text formats a value for the text based formats, leaving missing values
+// empty.
+func text(v any, timeLayout string) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(timeLayout)
+	default:
+		return fmt.Sprint(v)
+	}
+}
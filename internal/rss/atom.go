@@ -0,0 +1,117 @@
+package rss
+
+import "strings"
+
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+type AtomFeed struct {
+	Title    AtomText     `xml:"title"`
+	Subtitle AtomText     `xml:"subtitle"`
+	Link     []AtomLink   `xml:"link"`
+	Author   []AtomPerson `xml:"author"`
+	Entry    []AtomEntry  `xml:"entry"`
+}
+
+type AtomEntry struct {
+	ID        string         `xml:"id"`
+	Title     AtomText       `xml:"title"`
+	Link      []AtomLink     `xml:"link"`
+	Summary   AtomText       `xml:"summary"`
+	Content   AtomText       `xml:"content"`
+	Published string         `xml:"published"`
+	Updated   string         `xml:"updated"`
+	Author    []AtomPerson   `xml:"author"`
+	Category  []AtomCategory `xml:"category"`
+}
+
+type AtomPerson struct {
+	Name string `xml:"name"`
+}
+
+type AtomCategory struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr"`
+}
+
+type AtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+// AtomText holds an Atom text construct, which is either plain text, escaped
+// html or inline xhtml markup depending on its type attribute.
+type AtomText struct {
+	Type     string `xml:"type,attr"`
+	Text     string `xml:",chardata"`
+	InnerXML string `xml:",innerxml"`
+}
+
+func (t AtomText) String() string {
+	if t.Type == "xhtml" {
+		return strings.TrimSpace(t.InnerXML)
+	}
+	return strings.TrimSpace(t.Text)
+}
+
+func personNames(people []AtomPerson) string {
+	var names []string
+	for _, p := range people {
+		if name := strings.TrimSpace(p.Name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// alternateLink returns the href of the rel="alternate" link, which is the
+// default when rel is omitted, falling back to the first link present.
+func alternateLink(links []AtomLink) string {
+	for _, l := range links {
+		if l.Rel == "" || l.Rel == "alternate" {
+			return l.Href
+		}
+	}
+	if len(links) > 0 {
+		return links[0].Href
+	}
+	return ""
+}
+
+// toRSS takes the entry id as the GUID and falls back to the content for a
+// missing summary and to the updated time for a missing published time.
+func (f *AtomFeed) toRSS() *RSSFeed {
+	var feed RSSFeed
+	feed.Channel.Title = f.Title.String()
+	feed.Channel.Link = alternateLink(f.Link)
+	feed.Channel.Description = f.Subtitle.String()
+
+	for _, e := range f.Entry {
+		item := RSSItem{
+			Title:       e.Title.String(),
+			Link:        alternateLink(e.Link),
+			Description: e.Summary.String(),
+			PubDate:     e.Published,
+			GUID:        e.ID,
+			Content:     e.Content.String(),
+			Author:      personNames(e.Author),
+		}
+		if item.Description == "" {
+			item.Description = item.Content
+		}
+		// entries without an author inherit the feed's
+		if item.Author == "" {
+			item.Author = personNames(f.Author)
+		}
+		for _, c := range e.Category {
+			item.Categories = append(item.Categories, c.Term)
+		}
+		item.Categories = trimCategories(item.Categories)
+		if item.PubDate == "" {
+			item.PubDate = e.Updated
+		}
+		feed.Channel.Item = append(feed.Channel.Item, item)
+	}
+
+	return &feed
+}
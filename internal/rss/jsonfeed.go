@@ -0,0 +1,87 @@
+package rss
+
+import "strings"
+
+type JSONFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	Description string         `json:"description"`
+	Items       []JSONFeedItem `json:"items"`
+}
+
+type JSONFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url"`
+	ExternalURL   string           `json:"external_url"`
+	Title         string           `json:"title"`
+	ContentHTML   string           `json:"content_html"`
+	ContentText   string           `json:"content_text"`
+	Summary       string           `json:"summary"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified"`
+	Tags          []string         `json:"tags"`
+	Authors       []JSONFeedAuthor `json:"authors"`
+	// Author is the JSON Feed 1.0 single author field, replaced by Authors in 1.1
+	Author *JSONFeedAuthor `json:"author"`
+}
+
+type JSONFeedAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+func (i JSONFeedItem) authorNames() string {
+	authors := i.Authors
+	if len(authors) == 0 && i.Author != nil {
+		authors = []JSONFeedAuthor{*i.Author}
+	}
+
+	var names []string
+	for _, a := range authors {
+		if a.Name != "" {
+			names = append(names, a.Name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// toRSS prefers the HTML content over the text content, and external_url
+// for items without a url.
+func (f *JSONFeed) toRSS() *RSSFeed {
+	var feed RSSFeed
+	feed.Channel.Title = f.Title
+	feed.Channel.Link = f.HomePageURL
+	feed.Channel.Description = f.Description
+
+	for _, i := range f.Items {
+		item := RSSItem{
+			Title:       i.Title,
+			Link:        i.URL,
+			Description: i.ContentHTML,
+			PubDate:     i.DatePublished,
+			Author:      i.authorNames(),
+			GUID:        i.ID,
+			Content:     i.ContentHTML,
+			Categories:  trimCategories(i.Tags),
+		}
+		if item.Link == "" {
+			item.Link = i.ExternalURL
+		}
+		if item.Content == "" {
+			item.Content = i.ContentText
+		}
+		if item.Description == "" {
+			item.Description = i.ContentText
+		}
+		if item.Description == "" {
+			item.Description = i.Summary
+		}
+		if item.PubDate == "" {
+			item.PubDate = i.DateModified
+		}
+		feed.Channel.Item = append(feed.Channel.Item, item)
+	}
+
+	return &feed
+}
@@ -1,11 +1,23 @@
 package rss
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
 type RSSFeed struct {
 	Channel struct {
 		Title       string    `xml:"title"`
 		Link        string    `xml:"link"`
 		Description string    `xml:"description"`
 		Item        []RSSItem `xml:"item"`
+
+		TTL             string   `xml:"ttl"`
+		UpdatePeriod    string   `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+		UpdateFrequency string   `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
+		SkipHours       []string `xml:"skipHours>hour"`
+		SkipDays        []string `xml:"skipDays>day"`
 	} `xml:"channel"`
 }
 
@@ -14,4 +26,76 @@ type RSSItem struct {
 	Link        string `xml:"link"`
 	Description string `xml:"description"`
 	PubDate     string `xml:"pubDate"`
-}
\ No newline at end of file
+	Author      string `xml:"author"`
+	GUID        string `xml:"guid"`
+	// Content is the full article when the feed carries one besides the
+	// description, from content:encoded or Atom's content
+	Content    string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Creator    string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Categories []string `xml:"category"`
+}
+
+// Identity returns the item's GUID, or a hash of its summary for feeds that
+// don't provide one, so the same item can be recognised across fetches.
+func (i RSSItem) Identity() string {
+	if i.GUID != "" {
+		return i.GUID
+	}
+	return "sha256:" + i.SummaryHash()
+}
+
+// SummaryHash returns a hex encoded hash of the item's link, title and
+// description. It was the content hash of posts stored before content and
+// author were, and stays the identity of items without a GUID.
+func (i RSSItem) SummaryHash() string {
+	return hashText(i.Link + "\n" + i.Title + "\n" + i.Description)
+}
+
+// ContentHash returns a hex encoded hash of the item's link, title,
+// description, content and author, used to detect when a publisher edits an
+// item.
+func (i RSSItem) ContentHash() string {
+	return hashText(i.Link + "\n" + i.Title + "\n" + i.Description + "\n" + i.Content + "\n" + i.Author)
+}
+
+func hashText(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalize settles the RSS 2.0 item fields that have several sources:
+// Author becomes a name, preferring dc:creator, and blank categories are
+// dropped.
+func (f *RSSFeed) normalize() {
+	for i := range f.Channel.Item {
+		item := &f.Channel.Item[i]
+		if item.Creator != "" {
+			item.Author = strings.TrimSpace(item.Creator)
+		} else {
+			item.Author = authorName(item.Author)
+		}
+		item.Categories = trimCategories(item.Categories)
+	}
+}
+
+// authorName reduces an RSS 2.0 author, an email address usually followed by
+// the name in parentheses, to the name when there is one.
+func authorName(author string) string {
+	author = strings.TrimSpace(author)
+	if open := strings.Index(author, "("); open >= 0 && strings.HasSuffix(author, ")") {
+		if name := strings.TrimSpace(author[open+1 : len(author)-1]); name != "" {
+			return name
+		}
+	}
+	return author
+}
+
+func trimCategories(categories []string) []string {
+	var trimmed []string
+	for _, c := range categories {
+		if c = strings.TrimSpace(c); c != "" {
+			trimmed = append(trimmed, c)
+		}
+	}
+	return trimmed
+}
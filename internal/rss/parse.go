@@ -0,0 +1,88 @@
+package rss
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+
+	"golang.org/x/net/html/charset"
+)
+
+// Parse decodes an RSS 2.0, RSS 1.0 (RDF), Atom 1.0 or JSON Feed document.
+// JSON Feed is recognised by its content type or a leading brace, the XML
+// formats by their root element rather than by the URL. Every format is
+// mapped onto the RSS model so callers only deal with a single item type.
+func Parse(r io.Reader, contentType string) (*RSSFeed, error) {
+	br := bufio.NewReader(r)
+	if isJSONFeed(br, contentType) {
+		var feed JSONFeed
+		if err := json.NewDecoder(br).Decode(&feed); err != nil {
+			return nil, err
+		}
+		return feed.toRSS(), nil
+	}
+
+	dec := xml.NewDecoder(br)
+	// older feeds, RSS 1.0 ones especially, are often ISO-8859-1
+	dec.CharsetReader = charset.NewReaderLabel
+	root, err := rootElement(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case root.Name.Local == "rss":
+		var feed RSSFeed
+		if err := dec.DecodeElement(&feed, &root); err != nil {
+			return nil, err
+		}
+		feed.normalize()
+		return &feed, nil
+	case root.Name.Local == "feed" && root.Name.Space == atomNamespace:
+		var feed AtomFeed
+		if err := dec.DecodeElement(&feed, &root); err != nil {
+			return nil, err
+		}
+		return feed.toRSS(), nil
+	case root.Name.Local == "RDF" && root.Name.Space == rdfNamespace:
+		var feed RDFFeed
+		if err := dec.DecodeElement(&feed, &root); err != nil {
+			return nil, err
+		}
+		return feed.toRSS(), nil
+	default:
+		return nil, fmt.Errorf("unsupported feed format: <%s>", root.Name.Local)
+	}
+}
+
+func isJSONFeed(br *bufio.Reader, contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/feed+json" || mediaType == "application/json" {
+		return true
+	}
+
+	// servers often label JSON feeds text/plain or octet-stream, so fall back
+	// to sniffing the first non-whitespace byte
+	peek, _ := br.Peek(512)
+	return bytes.HasPrefix(bytes.TrimLeft(peek, " \t\r\n"), []byte("{"))
+}
+
+func rootElement(dec *xml.Decoder) (xml.StartElement, error) {
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return xml.StartElement{}, errors.New("empty feed document")
+		}
+		if err != nil {
+			return xml.StartElement{}, err
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start, nil
+		}
+	}
+}
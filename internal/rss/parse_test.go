@@ -0,0 +1,301 @@
+package rss
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		doc         string
+		title       string
+		link        string
+		items       []RSSItem
+	}{
+		{
+			name: "rss",
+			doc: `<?xml version="1.0"?>
+<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:content="http://purl.org/rss/1.0/modules/content/">
+<channel>
+  <title>Example</title>
+  <link>https://example.com/</link>
+  <item>
+    <title>First</title>
+    <link>https://example.com/1</link>
+    <description>&lt;p&gt;Summary&lt;/p&gt;</description>
+    <content:encoded><![CDATA[<p>Full text</p>]]></content:encoded>
+    <pubDate>Mon, 02 Jan 2006 15:04:05 GMT</pubDate>
+    <guid isPermaLink="false">tag:example.com,2006:1</guid>
+    <author>ann@example.com (Ann)</author>
+    <category> Go </category>
+    <category></category>
+    <category>Feeds</category>
+  </item>
+  <item>
+    <title>Second</title>
+    <author>bob@example.com</author>
+    <dc:creator>Bob</dc:creator>
+  </item>
+</channel>
+</rss>`,
+			title: "Example",
+			link:  "https://example.com/",
+			items: []RSSItem{
+				{
+					Title:       "First",
+					Link:        "https://example.com/1",
+					Description: "<p>Summary</p>",
+					Content:     "<p>Full text</p>",
+					PubDate:     "Mon, 02 Jan 2006 15:04:05 GMT",
+					GUID:        "tag:example.com,2006:1",
+					Author:      "Ann",
+					Categories:  []string{"Go", "Feeds"},
+				},
+				{Title: "Second", Author: "Bob", Creator: "Bob"},
+			},
+		},
+		{
+			name:        "rss with content type",
+			contentType: "application/rss+xml; charset=utf-8",
+			doc:         `<rss><channel><title>Plain</title><item><title>Only</title><author>carol@example.com</author></item></channel></rss>`,
+			title:       "Plain",
+			items:       []RSSItem{{Title: "Only", Author: "carol@example.com"}},
+		},
+		{
+			name: "atom",
+			doc: `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title type="html">Atom &amp;amp; more</title>
+  <subtitle>Sub</subtitle>
+  <link rel="self" href="https://example.com/feed.atom"/>
+  <link href="https://example.com/"/>
+  <author><name>Feed Author</name></author>
+  <entry>
+    <id>urn:uuid:1</id>
+    <title>Entry</title>
+    <link rel="enclosure" href="https://example.com/1.mp3"/>
+    <link rel="alternate" type="text/html" href="https://example.com/1"/>
+    <summary>Short</summary>
+    <content type="html">&lt;p&gt;Long&lt;/p&gt;</content>
+    <published>2006-01-02T15:04:05Z</published>
+    <updated>2006-01-03T15:04:05Z</updated>
+    <author><name>Ann</name></author>
+    <author><name>Bob</name></author>
+    <category term="go" label="Go"/>
+    <category term=" "/>
+  </entry>
+  <entry>
+    <id>urn:uuid:2</id>
+    <title type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml">Rich <b>title</b></div></title>
+    <link rel="related" href="https://example.com/related"/>
+    <content type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml"><p>Body</p></div></content>
+    <updated>2006-01-04T15:04:05Z</updated>
+  </entry>
+</feed>`,
+			title: "Atom &amp; more",
+			link:  "https://example.com/",
+			items: []RSSItem{
+				{
+					Title:       "Entry",
+					Link:        "https://example.com/1",
+					Description: "Short",
+					Content:     "<p>Long</p>",
+					PubDate:     "2006-01-02T15:04:05Z",
+					GUID:        "urn:uuid:1",
+					Author:      "Ann, Bob",
+					Categories:  []string{"go"},
+				},
+				{
+					Title:       `<div xmlns="http://www.w3.org/1999/xhtml">Rich <b>title</b></div>`,
+					Link:        "https://example.com/related",
+					Description: `<div xmlns="http://www.w3.org/1999/xhtml"><p>Body</p></div>`,
+					Content:     `<div xmlns="http://www.w3.org/1999/xhtml"><p>Body</p></div>`,
+					PubDate:     "2006-01-04T15:04:05Z",
+					GUID:        "urn:uuid:2",
+					Author:      "Feed Author",
+				},
+			},
+		},
+		{
+			name:        "json feed 1.1",
+			contentType: "application/feed+json",
+			doc: `{
+  "version": "https://jsonfeed.org/version/1.1",
+  "title": "JSON",
+  "home_page_url": "https://example.com/",
+  "items": [
+    {
+      "id": "1",
+      "url": "https://example.com/1",
+      "title": "HTML",
+      "content_html": "<p>Hi</p>",
+      "content_text": "Hi",
+      "date_published": "2006-01-02T15:04:05Z",
+      "authors": [{"name": "Ann"}, {"name": ""}, {"name": "Bob"}],
+      "tags": ["go", " "]
+    },
+    {
+      "id": "2",
+      "external_url": "https://other.example/2",
+      "content_text": "Text only",
+      "date_modified": "2006-01-03T15:04:05Z"
+    },
+    {
+      "id": "3",
+      "summary": "Summary only"
+    }
+  ]
+}`,
+			title: "JSON",
+			link:  "https://example.com/",
+			items: []RSSItem{
+				{
+					Title:       "HTML",
+					Link:        "https://example.com/1",
+					Description: "<p>Hi</p>",
+					Content:     "<p>Hi</p>",
+					PubDate:     "2006-01-02T15:04:05Z",
+					GUID:        "1",
+					Author:      "Ann, Bob",
+					Categories:  []string{"go"},
+				},
+				{
+					Link:        "https://other.example/2",
+					Description: "Text only",
+					Content:     "Text only",
+					PubDate:     "2006-01-03T15:04:05Z",
+					GUID:        "2",
+				},
+				{Description: "Summary only", GUID: "3"},
+			},
+		},
+		{
+			name: "json feed 1.0 sniffed",
+			doc: `
+  {"version": "https://jsonfeed.org/version/1", "title": "Old",
+   "items": [{"id": "a", "title": "A", "author": {"name": "Ann"}}]}`,
+			title: "Old",
+			items: []RSSItem{{Title: "A", GUID: "a", Author: "Ann"}},
+		},
+		{
+			name: "rdf in latin-1",
+			doc: "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n" +
+				`<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/">` +
+				"<channel><title>Universit\xe4t</title></channel>" +
+				`<item rdf:about="https://example.org/r">` + "<title>Caf\xe9</title></item>" +
+				"</rdf:RDF>",
+			title: "Universität",
+			items: []RSSItem{{Title: "Café", GUID: "https://example.org/r"}},
+		},
+		{
+			name: "rdf",
+			doc: `<?xml version="1.0"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/"
+  xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:sy="http://purl.org/rss/1.0/modules/syndication/">
+  <channel rdf:about="https://example.org/">
+    <title>RDF</title>
+    <link>https://example.org/</link>
+    <sy:updatePeriod>daily</sy:updatePeriod>
+    <sy:updateFrequency>2</sy:updateFrequency>
+  </channel>
+  <item rdf:about="https://example.org/1">
+    <title>One</title>
+    <link>https://example.org/1</link>
+    <description>Desc</description>
+    <dc:date>2006-01-02T15:04:05+00:00</dc:date>
+    <dc:creator> Ann </dc:creator>
+    <dc:subject>Physics</dc:subject>
+  </item>
+</rdf:RDF>`,
+			title: "RDF",
+			link:  "https://example.org/",
+			items: []RSSItem{
+				{
+					Title:       "One",
+					Link:        "https://example.org/1",
+					Description: "Desc",
+					PubDate:     "2006-01-02T15:04:05+00:00",
+					GUID:        "https://example.org/1",
+					Author:      "Ann",
+					Categories:  []string{"Physics"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed, err := Parse(strings.NewReader(tt.doc), tt.contentType)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if feed.Channel.Title != tt.title {
+				t.Errorf("title = %q, want %q", feed.Channel.Title, tt.title)
+			}
+			if feed.Channel.Link != tt.link {
+				t.Errorf("link = %q, want %q", feed.Channel.Link, tt.link)
+			}
+			if len(feed.Channel.Item) != len(tt.items) {
+				t.Fatalf("got %d items, want %d", len(feed.Channel.Item), len(tt.items))
+			}
+			for i, want := range tt.items {
+				if got := feed.Channel.Item[i]; !reflect.DeepEqual(got, want) {
+					t.Errorf("item %d:\n got %#v\nwant %#v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+	}{
+		{"empty", ""},
+		{"html page", "<!DOCTYPE html><html><body>not a feed</body></html>"},
+		{"atom root without namespace", "<feed><title>x</title></feed>"},
+		{"broken json", "{\"items\": ["},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(strings.NewReader(tt.doc), ""); err == nil {
+				t.Errorf("Parse(%q) succeeded, want an error", tt.doc)
+			}
+		})
+	}
+}
+
+// Stored posts are matched by these values, changing them duplicates or
+// rewrites every post already stored.
+func TestItemHashes(t *testing.T) {
+	item := RSSItem{Link: "https://example.com/a", Title: "Title", Description: "Desc"}
+	// same as the backfill in 007_post_revisions.sql
+	const summary = "50f5a75deb643ef2d35c2719889fde57e7e1e07ad7f3eea88ef638d022d4f38c"
+
+	if got := item.SummaryHash(); got != summary {
+		t.Errorf("SummaryHash = %s, want %s", got, summary)
+	}
+	if got := item.Identity(); got != "sha256:"+summary {
+		t.Errorf("Identity without GUID = %s", got)
+	}
+	if got, want := item.ContentHash(), "f3b79e0787a132a4a03f4c4b6bd57f230c58bbfe1da668439bab8b8545f071ae"; got != want {
+		t.Errorf("ContentHash = %s, want %s", got, want)
+	}
+
+	item.Content = "<p>Body</p>"
+	item.Author = "Ann"
+	if got, want := item.ContentHash(), "4f70c81bdc9868c8f8dc50aa20a4aa4d4879eac186f89c189d59a92e7408ca8c"; got != want {
+		t.Errorf("ContentHash with content and author = %s, want %s", got, want)
+	}
+	if got := item.Identity(); got != "sha256:"+summary {
+		t.Errorf("content changed the identity: %s", got)
+	}
+
+	item.GUID = "tag:example.com,2006:1"
+	if got := item.Identity(); got != item.GUID {
+		t.Errorf("Identity = %s, want the GUID", got)
+	}
+}
@@ -0,0 +1,57 @@
+package rss
+
+import "strings"
+
+const (
+	rdfNamespace = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	dcNamespace  = "http://purl.org/dc/elements/1.1/"
+)
+
+// RDFFeed is an RSS 1.0 document, where items are siblings of the channel
+// under the rdf:RDF root instead of being nested inside it.
+type RDFFeed struct {
+	Channel struct {
+		Title           string `xml:"title"`
+		Link            string `xml:"link"`
+		Description     string `xml:"description"`
+		UpdatePeriod    string `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+		UpdateFrequency string `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
+	} `xml:"channel"`
+	Item []RDFItem `xml:"item"`
+}
+
+type RDFItem struct {
+	About       string   `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	Date        string   `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Content     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Subject     []string `xml:"http://purl.org/dc/elements/1.1/ subject"`
+}
+
+// toRSS takes rdf:about as the GUID and dc:date as the publication date.
+func (f *RDFFeed) toRSS() *RSSFeed {
+	var feed RSSFeed
+	feed.Channel.Title = f.Channel.Title
+	feed.Channel.Link = f.Channel.Link
+	feed.Channel.Description = f.Channel.Description
+	feed.Channel.UpdatePeriod = f.Channel.UpdatePeriod
+	feed.Channel.UpdateFrequency = f.Channel.UpdateFrequency
+
+	for _, i := range f.Item {
+		feed.Channel.Item = append(feed.Channel.Item, RSSItem{
+			Title:       i.Title,
+			Link:        i.Link,
+			Description: i.Description,
+			PubDate:     i.Date,
+			Author:      strings.TrimSpace(i.Creator),
+			GUID:        i.About,
+			Content:     i.Content,
+			Categories:  trimCategories(i.Subject),
+		})
+	}
+
+	return &feed
+}
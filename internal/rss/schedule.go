@@ -0,0 +1,64 @@
+package rss
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+var updatePeriods = map[string]time.Duration{
+	"hourly":  time.Hour,
+	"daily":   24 * time.Hour,
+	"weekly":  7 * 24 * time.Hour,
+	"monthly": 30 * 24 * time.Hour,
+	"yearly":  365 * 24 * time.Hour,
+}
+
+// RefreshInterval returns how often the publisher asks to be polled, taken
+// from the channel's <ttl> or the syndication module's updatePeriod and
+// updateFrequency, whichever is longer. It returns 0 when neither is set.
+func (f *RSSFeed) RefreshInterval() time.Duration {
+	var interval time.Duration
+	if minutes, err := strconv.Atoi(strings.TrimSpace(f.Channel.TTL)); err == nil && minutes > 0 {
+		interval = time.Duration(minutes) * time.Minute
+	}
+
+	if period, ok := updatePeriods[strings.ToLower(strings.TrimSpace(f.Channel.UpdatePeriod))]; ok {
+		frequency, err := strconv.Atoi(strings.TrimSpace(f.Channel.UpdateFrequency))
+		if err != nil || frequency < 1 {
+			frequency = 1
+		}
+		interval = max(interval, period/time.Duration(frequency))
+	}
+
+	return interval
+}
+
+// SkipHours returns the UTC hours, 0 to 23, in which the channel asks not to
+// be fetched.
+func (f *RSSFeed) SkipHours() []int32 {
+	var hours []int32
+	for _, h := range f.Channel.SkipHours {
+		hour, err := strconv.Atoi(strings.TrimSpace(h))
+		if err != nil || hour < 0 || hour > 24 {
+			continue
+		}
+		// some feeds number hours 1 to 24
+		hours = append(hours, int32(hour%24))
+	}
+	return hours
+}
+
+// SkipDays returns the days, as time.Weekday values, on which the channel
+// asks not to be fetched.
+func (f *RSSFeed) SkipDays() []int32 {
+	var days []int32
+	for _, d := range f.Channel.SkipDays {
+		for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+			if strings.EqualFold(strings.TrimSpace(d), weekday.String()) {
+				days = append(days, int32(weekday))
+			}
+		}
+	}
+	return days
+}
@@ -0,0 +1,97 @@
+package schedule
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// MinInterval is the shortest delay between two fetches of a feed.
+	MinInterval = 10 * time.Second
+	// MaxPublisherInterval caps intervals requested by publishers, so a
+	// "monthly" feed is still checked daily.
+	MaxPublisherInterval = 24 * time.Hour
+)
+
+// Hints are the inputs that decide when a feed is fetched next.
+type Hints struct {
+	// Interval is set by the user and overrides the publisher's cadence.
+	Interval time.Duration
+	// Refresh is the cadence advertised by the feed through <ttl> or the
+	// syndication module.
+	Refresh time.Duration
+	// CacheLifetime comes from the Cache-Control or Expires response headers.
+	// The feed is not fetched again before it runs out.
+	CacheLifetime time.Duration
+	// SkipHours and SkipDays list UTC hours and weekdays to avoid.
+	SkipHours []int32
+	SkipDays  []int32
+}
+
+// Delay returns how long to wait after now before fetching the feed again.
+// fallback is used when neither the user nor the publisher picked a cadence.
+func Delay(now time.Time, fallback time.Duration, h Hints) time.Duration {
+	interval := fallback
+	switch {
+	case h.Interval > 0:
+		interval = h.Interval
+	case h.Refresh > 0:
+		interval = min(h.Refresh, MaxPublisherInterval)
+	}
+	if h.Interval <= 0 {
+		interval = max(interval, min(h.CacheLifetime, MaxPublisherInterval))
+	}
+	interval = max(interval, MinInterval)
+
+	next := now.Add(interval).UTC()
+	// skipHours and skipDays are at hour granularity, a week is enough to
+	// find an allowed slot unless every hour is skipped
+	for range 7 * 24 {
+		if !slices.Contains(h.SkipHours, int32(next.Hour())) && !slices.Contains(h.SkipDays, int32(next.Weekday())) {
+			break
+		}
+		next = next.Truncate(time.Hour).Add(time.Hour)
+	}
+
+	return next.Sub(now)
+}
+
+// Backoff returns the delay before retrying a feed that failed failures times
+// in a row, doubling interval for each failure up to MaxPublisherInterval.
+func Backoff(interval time.Duration, failures int) time.Duration {
+	delay := max(interval, MinInterval)
+	for i := 1; i < failures && delay < MaxPublisherInterval; i++ {
+		delay *= 2
+	}
+	return min(delay, MaxPublisherInterval)
+}
+
+// CacheLifetime returns how long a response may be cached according to its
+// Cache-Control max-age or, failing that, its Expires header.
+func CacheLifetime(header http.Header, now time.Time) time.Duration {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		directive = strings.TrimSpace(strings.ToLower(directive))
+		if directive == "no-cache" || directive == "no-store" {
+			return 0
+		}
+		if value, ok := strings.CutPrefix(directive, "max-age="); ok {
+			seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+			if err != nil || seconds < 0 {
+				return 0
+			}
+			return time.Duration(seconds) * time.Second
+		}
+	}
+
+	expires, err := http.ParseTime(header.Get("Expires"))
+	if err != nil {
+		return 0
+	}
+	if date, err := http.ParseTime(header.Get("Date")); err == nil {
+		now = date
+	}
+	return max(expires.Sub(now), 0)
+}
@@ -0,0 +1,195 @@
+// Package tui draws full screen interfaces on an ANSI terminal. A Screen puts
+// the terminal in raw mode on the alternate screen, reads key presses and
+// redraws the whole screen from a list of lines.
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+const (
+	escape = "\x1b["
+	reset  = escape + "0m"
+)
+
+var ErrNotTerminal = errors.New("not a terminal")
+
+type Screen struct {
+	in    *os.File
+	out   *bufio.Writer
+	state *term.State
+}
+
+// Open switches the terminal to raw mode and the alternate screen. Close
+// must be called to restore it.
+func Open() (*Screen, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return nil, ErrNotTerminal
+	}
+	state, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Screen{
+		in:    os.Stdin,
+		out:   bufio.NewWriter(os.Stdout),
+		state: state,
+	}
+	// alternate screen, hidden cursor
+	s.out.WriteString(escape + "?1049h" + escape + "?25l")
+	return s, s.out.Flush()
+}
+
+func (s *Screen) Close() error {
+	s.out.WriteString(reset + escape + "?25h" + escape + "?1049l")
+	s.out.Flush()
+	return term.Restore(int(s.in.Fd()), s.state)
+}
+
+// Size returns the terminal's width and height in cells.
+func (s *Screen) Size() (int, int) {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		return 80, 24
+	}
+	return width, height
+}
+
+// Draw replaces the screen with lines, one per row. Lines must already fit
+// the width, see Fit.
+func (s *Screen) Draw(lines []string) error {
+	s.out.WriteString(escape + "H")
+	for i, line := range lines {
+		if i > 0 {
+			s.out.WriteString("\r\n")
+		}
+		s.out.WriteString(line)
+		s.out.WriteString(reset + escape + "K")
+	}
+	s.out.WriteString(escape + "J")
+	return s.out.Flush()
+}
+
+// ReadKey blocks until a key is pressed. Printable keys are returned as
+// themselves, others by name: "up", "down", "left", "right", "pgup",
+// "pgdown", "home", "end", "enter", "tab", "backtab", "backspace", "esc" and
+// "ctrl+a" through "ctrl+z".
+func (s *Screen) ReadKey() (string, error) {
+	buf := make([]byte, 32)
+	n, err := s.in.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return parseKey(buf[:n]), nil
+}
+
+var escapeKeys = map[string]string{
+	"[A": "up", "[B": "down", "[C": "right", "[D": "left",
+	"OA": "up", "OB": "down", "OC": "right", "OD": "left",
+	"[5~": "pgup", "[6~": "pgdown",
+	"[H": "home", "[F": "end", "OH": "home", "OF": "end",
+	"[1~": "home", "[4~": "end", "[7~": "home", "[8~": "end",
+	"[Z": "backtab",
+}
+
+func parseKey(b []byte) string {
+	switch {
+	case len(b) == 0:
+		return ""
+	case b[0] == 0x1b:
+		if len(b) == 1 {
+			return "esc"
+		}
+		if name, ok := escapeKeys[string(b[1:])]; ok {
+			return name
+		}
+		return ""
+	case b[0] == '\r' || b[0] == '\n':
+		return "enter"
+	case b[0] == '\t':
+		return "tab"
+	case b[0] == 0x7f || b[0] == 0x08:
+		return "backspace"
+	case b[0] < 0x20:
+		return "ctrl+" + string(rune('a'+b[0]-1))
+	}
+	r, _ := utf8.DecodeRune(b)
+	return string(r)
+}
+
+// Fit truncates or pads s with spaces to exactly width cells. C0 and C1
+// control characters are replaced, each rune is taken to be one cell wide.
+func Fit(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range s {
+		if n == width {
+			break
+		}
+		if r < 0x20 || r == 0x7f || (r >= 0x80 && r < 0xa0) {
+			r = ' '
+		}
+		b.WriteRune(r)
+		n++
+	}
+	if n == width && utf8.RuneCountInString(s) > width && width > 1 {
+		// mark the cut with an ellipsis
+		runes := []rune(b.String())
+		runes[width-1] = '…'
+		return string(runes)
+	}
+	return b.String() + strings.Repeat(" ", width-n)
+}
+
+// Wrap breaks text into lines of at most width cells, at spaces where
+// possible. Newlines in text are kept.
+func Wrap(text string, width int) []string {
+	if width <= 0 {
+		return nil
+	}
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			for utf8.RuneCountInString(word) > width {
+				if line != "" {
+					lines = append(lines, line)
+					line = ""
+				}
+				runes := []rune(word)
+				lines = append(lines, string(runes[:width]))
+				word = string(runes[width:])
+			}
+			if word == "" {
+				continue
+			}
+			switch {
+			case line == "":
+				line = word
+			case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) <= width:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Styles wrap text that was already fitted, they take no cells.
+
+func Bold(s string) string    { return escape + "1m" + s + reset }
+func Dim(s string) string     { return escape + "2m" + s + reset }
+func Reverse(s string) string { return escape + "7m" + s + reset }
@@ -3,26 +3,17 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"gator/internal/config"
 	"gator/internal/database"
+	"gator/internal/render"
 	"os"
+	"strings"
 
 	_ "github.com/lib/pq"
 )
 
-func (c *Commands) run(s *state, cmd command) error {
-	handler, ok := c.commands[cmd.Name]
-	if !ok {
-		return fmt.Errorf("invalid command name: '%s'", cmd.Name)
-	}
-	return handler(s, cmd)
-}
-
-func (c *Commands) register(name string, f func(*state, command) error) {
-	c.commands[name] = f
-}
-
 func middlewareLoggedIn(handler func(s *state, cmd command, user database.User) error) func(*state, command) error {
 	return func(s *state, cmd command) error {
 		user, err := s.db.GetUser(context.Background(), s.Config.CurrentUsername)
@@ -34,50 +25,252 @@ func middlewareLoggedIn(handler func(s *state, cmd command, user database.User)
 	}
 }
 
+// outputFlag removes the global --output flag from args, wherever it appears
+// before a "--", and returns the format it selects.
+func outputFlag(args []string) (render.Format, []string, error) {
+	format := render.Table
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--" {
+			// the command's own parser drops the "--"
+			rest = append(rest, args[i:]...)
+			break
+		}
+		value, ok := strings.CutPrefix(args[i], "--output=")
+		if !ok && (args[i] == "--output" || args[i] == "-o") {
+			if i+1 >= len(args) {
+				return "", nil, errors.New("missing value for --output")
+			}
+			i++
+			value, ok = args[i], true
+		}
+		if !ok {
+			rest = append(rest, args[i])
+			continue
+		}
+
+		var err error
+		format, err = render.ParseFormat(value)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+	return format, rest, nil
+}
+
 func main() {
 	cfg, err := config.Read()
 	if err != nil {
-		panic(err)
+		fmt.Fprintf(os.Stderr, "failed to read config: %v\n", err)
+		os.Exit(exitError)
 	}
 	s := state{
 		Config: cfg,
 	}
 	cmds := Commands{
-		commands: make(map[string]func(*state, command) error),
+		commands: make(map[string]*commandSpec),
 	}
 
-	cmds.register("login", handleLogin)
-	cmds.register("register", handleRegister)
-	cmds.register("reset", handleReset)
-	cmds.register("users", handleUsers)
-	cmds.register("agg", handleAgg)
-	cmds.register("addfeed", middlewareLoggedIn(handleAddFeed))
-	cmds.register("feeds", handleListFeeds)
-	cmds.register("follow", middlewareLoggedIn(handleFollow))
-	cmds.register("following", middlewareLoggedIn(handleFollowing))
-	cmds.register("unfollow", middlewareLoggedIn(handleUnfollow))
-	cmds.register("browse", middlewareLoggedIn(handleBrowse))
+	cmds.register(commandSpec{
+		Name:    "help",
+		Summary: "List the commands or describe one",
+		Args:    []arg{{Name: "command", Optional: true, Complete: cmds.completeCommands}},
+		Handler: cmds.handleHelp,
+	})
+	cmds.register(commandSpec{
+		Name:    "completion",
+		Summary: "Print a shell completion script",
+		Details: "Load it with 'source <(gator completion bash)', 'source <(gator completion zsh)'\n" +
+			"or 'gator completion fish | source'.",
+		Args:    []arg{{Name: "shell", Complete: completeWords("bash", "zsh", "fish")}},
+		Handler: handleCompletion,
+	})
+	cmds.register(commandSpec{
+		Name:    completeCommand,
+		Handler: cmds.handleComplete,
+		Hidden:  true,
+		RawArgs: true,
+	})
+	cmds.register(commandSpec{
+		Name:    "login",
+		Summary: "Switch to an existing user",
+		Args:    []arg{{Name: "username", Complete: completeUsers}},
+		Handler: handleLogin,
+	})
+	cmds.register(commandSpec{
+		Name:    "register",
+		Summary: "Create a user and switch to it",
+		Args:    []arg{{Name: "username"}},
+		Handler: handleRegister,
+	})
+	cmds.register(commandSpec{
+		Name:    "reset",
+		Summary: "Delete every user along with their feeds and follows",
+		Handler: handleReset,
+	})
+	cmds.register(commandSpec{
+		Name:    "users",
+		Summary: "List users",
+		Handler: handleUsers,
+	})
+	cmds.register(commandSpec{
+		Name:    "agg",
+		Summary: "Fetch due feeds until interrupted",
+		Details: "interval is the default time between fetches of a feed, at least 10s. workers\n" +
+			"(default 4) feeds are fetched at once, at most per-host (default 1) from one host.",
+		Args:    []arg{{Name: "interval"}, {Name: "workers", Optional: true}, {Name: "per-host", Optional: true}},
+		Handler: handleAgg,
+	})
+	cmds.register(commandSpec{
+		Name:    "addfeed",
+		Summary: "Add a feed and follow it",
+		Details: "url may be a page that links to its feed.",
+		Args:    []arg{{Name: "name"}, {Name: "url"}},
+		Handler: middlewareLoggedIn(handleAddFeed),
+	})
+	cmds.register(commandSpec{
+		Name:    "feeds",
+		Summary: "List feeds",
+		Flags:   []flag{{Name: "health", Usage: "show fetch failures and schedule"}},
+		Handler: handleListFeeds,
+	})
+	cmds.register(commandSpec{
+		Name:    "interval",
+		Summary: "Set how often a feed you added is fetched",
+		Details: "Without a duration the feed goes back to the publisher's schedule.",
+		Args:    []arg{{Name: "url", Complete: completeFeeds}, {Name: "duration", Optional: true}},
+		Handler: middlewareLoggedIn(handleInterval),
+	})
+	cmds.register(commandSpec{
+		Name:    "enablefeed",
+		Summary: "Resume fetching a feed disabled after repeated failures",
+		Args:    []arg{{Name: "url", Complete: completeFeeds}},
+		Handler: handleEnableFeed,
+	})
+	cmds.register(commandSpec{
+		Name:    "follow",
+		Summary: "Follow a feed added with addfeed, by its URL or its site's",
+		Args:    []arg{{Name: "url", Complete: completeFeeds}},
+		Handler: middlewareLoggedIn(handleFollow),
+	})
+	cmds.register(commandSpec{
+		Name:    "following",
+		Summary: "List the feeds you follow",
+		Handler: middlewareLoggedIn(handleFollowing),
+	})
+	cmds.register(commandSpec{
+		Name:    "unfollow",
+		Summary: "Stop following a feed",
+		Args:    []arg{{Name: "url", Complete: completeFollowedFeeds}},
+		Handler: middlewareLoggedIn(handleUnfollow),
+	})
+	cmds.register(commandSpec{
+		Name:    "browse",
+		Summary: "List posts from the feeds you follow",
+		Details: "limit defaults to 2. Times are an age such as 7d, 2w or 36h, or a date.",
+		Args:    []arg{{Name: "limit", Optional: true}},
+		Flags: []flag{
+			{Name: "feed", Value: "url", Usage: "only posts from this feed", Complete: completeFollowedFeeds},
+			{Name: "category", Value: "name", Usage: "only posts in this category", Complete: completeCategories},
+			{Name: "since", Value: "time", Usage: "only posts published, or fetched with --sort fetched, after this time"},
+			{Name: "until", Value: "time", Usage: "only posts published, or fetched with --sort fetched, before this time"},
+			{Name: "offset", Value: "n", Usage: "skip the first n posts"},
+			{Name: "page", Value: "cursor", Usage: "continue after the cursor printed by a previous browse"},
+			{Name: "sort", Value: "published|fetched", Usage: "sort by publish or fetch time, newest first", Complete: completeWords("published", "fetched")},
+			{Name: "unread", Usage: "only posts you haven't read"},
+		},
+		Handler: middlewareLoggedIn(handleBrowse),
+	})
+	cmds.register(commandSpec{
+		Name:    "tui",
+		Summary: "Read the feeds you follow in a full screen terminal reader",
+		Details: "Keys: tab/shift-tab or h/l switch pane, j/k or arrows move, space/b page,\n" +
+			"enter opens a post, r marks it read, R marks the selected feed read, s saves\n" +
+			"the post, o opens it in $BROWSER, u toggles unread only and q quits.",
+		Handler: middlewareLoggedIn(handleTUI),
+	})
+	cmds.register(commandSpec{
+		Name:    "read",
+		Summary: "Mark a post as read",
+		Args:    []arg{{Name: "post-id"}},
+		Handler: middlewareLoggedIn(handleRead),
+	})
+	cmds.register(commandSpec{
+		Name:    "show",
+		Summary: "Print a post's content as text",
+		Args:    []arg{{Name: "post-id"}},
+		Flags:   []flag{{Name: "width", Value: "n", Usage: "wrap at n columns instead of the terminal width"}},
+		Handler: handleShow,
+	})
+	cmds.register(commandSpec{
+		Name:    "mark-read",
+		Summary: "Mark many posts as read",
+		Details: "At least one flag is required, use --all to mark every post.",
+		Flags: []flag{
+			{Name: "feed", Value: "url", Usage: "only posts from this feed", Complete: completeFollowedFeeds},
+			{Name: "before", Value: "time", Usage: "only posts published before this time, an age such as 7d or a date"},
+			{Name: "all", Usage: "every post from the feeds you follow"},
+		},
+		Handler: middlewareLoggedIn(handleMarkRead),
+	})
+	cmds.register(commandSpec{
+		Name:    "save",
+		Summary: "Save a post so it is kept even if its feed goes away",
+		Args:    []arg{{Name: "post-id"}},
+		Handler: middlewareLoggedIn(handleSave),
+	})
+	cmds.register(commandSpec{
+		Name:    "unsave",
+		Summary: "Remove a saved post",
+		Args:    []arg{{Name: "id"}},
+		Handler: middlewareLoggedIn(handleUnsave),
+	})
+	cmds.register(commandSpec{
+		Name:    "saved",
+		Summary: "List your saved posts",
+		Handler: middlewareLoggedIn(handleSaved),
+	})
+	cmds.register(commandSpec{
+		Name:    "search",
+		Summary: "Search the posts of the feeds you follow",
+		Args:    []arg{{Name: "query", Variadic: true}},
+		Flags: []flag{
+			{Name: "feed", Value: "url", Usage: "only posts from this feed", Complete: completeFollowedFeeds},
+			{Name: "category", Value: "name", Usage: "only posts in this category", Complete: completeCategories},
+			{Name: "since", Value: "time", Usage: "only posts published after this time, an age such as 7d or a date"},
+			{Name: "limit", Value: "n", Usage: "show at most n posts (default 10)"},
+		},
+		Handler: middlewareLoggedIn(handleSearch),
+	})
+	cmds.register(commandSpec{
+		Name:    "import",
+		Summary: "Add and follow the feeds in a subscription list",
+		Details: "The only supported format is opml.",
+		Args:    []arg{{Name: "format", Complete: completeWords("opml")}, {Name: "file"}},
+		Handler: middlewareLoggedIn(handleImport),
+	})
+	cmds.register(commandSpec{
+		Name:    "export",
+		Summary: "Write the feeds you follow as a subscription list",
+		Details: "The only supported format is opml. Without a file the list is written to stdout.",
+		Args:    []arg{{Name: "format", Complete: completeWords("opml")}, {Name: "file", Optional: true}},
+		Handler: middlewareLoggedIn(handleExport),
+	})
 
 	db, err := sql.Open("postgres", cfg.DbUrl)
 	dbQueries := database.New(db)
 	s.db = dbQueries
+	s.conn = db
 
-	if len(os.Args) < 2 {
-		panic("error: missing command")
-	}
-	args := os.Args[2:]
-	cmdName := os.Args[1]
-
-	fmt.Println(args)
-	cmd := command{
-		Name: cmdName,
-		Args: args,
+	// the completion callback gets the command line being typed as is
+	args := os.Args[1:]
+	if len(args) == 0 || args[0] != completeCommand {
+		s.output, args, err = outputFlag(args)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(exitUsage)
+		}
 	}
 
-	err = cmds.run(&s, cmd)
-
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
+	os.Exit(cmds.run(&s, args))
 }
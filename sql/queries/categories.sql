@@ -0,0 +1,28 @@
+-- name: CreateCategory :one
+-- Returns the category with the given name, creating it if needed. Names are
+-- stored lower case so filters match regardless of how feeds spell them.
+INSERT INTO categories (id, name)
+VALUES (sqlc.arg(id), LOWER(sqlc.arg(name)))
+ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+RETURNING *;
+
+-- name: GetCategories :many
+SELECT name FROM categories ORDER BY name;
+
+-- name: GetPostCategories :many
+-- Returns the post with the given GUID in a feed once per category, or once
+-- with a NULL name when it has none.
+SELECT posts.id, categories.name
+FROM posts
+LEFT JOIN post_categories ON post_categories.post_id = posts.id
+LEFT JOIN categories ON categories.id = post_categories.category_id
+WHERE posts.feed_id = $1 AND posts.guid = $2
+ORDER BY categories.name;
+
+-- name: AddPostCategory :exec
+INSERT INTO post_categories (post_id, category_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: DeletePostCategories :exec
+DELETE FROM post_categories WHERE post_id = $1;
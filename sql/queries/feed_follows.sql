@@ -18,12 +18,40 @@ SELECT
     users.id AS user_id,
     feeds.name AS feed_name,
     feeds.url AS feed_url,
-    feeds.id AS feed_id
+    feeds.id AS feed_id,
+    feed_follows.folder AS folder,
+    (
+        SELECT COUNT(*)
+        FROM posts
+        WHERE posts.feed_id = feeds.id
+            AND NOT EXISTS (
+                SELECT 1
+                FROM post_reads
+                WHERE post_reads.post_id = posts.id AND post_reads.user_id = users.id
+            )
+    ) AS unread
 FROM feed_follows
 INNER JOIN users ON users.id = feed_follows.user_id
 INNER JOIN feeds ON feeds.id = feed_follows.feed_id
-WHERE users.name = $1;
+WHERE users.name = $1
+ORDER BY feed_follows.folder NULLS FIRST, feeds.name;
 
 -- name: DeleteFollow :exec
 DELETE FROM feed_follows
-WHERE user_id = $1 AND feed_id = $2;
\ No newline at end of file
+WHERE user_id = $1 AND feed_id = $2;
+
+-- name: MoveFeedFollows :exec
+-- Moves follows to another feed, skipping users who already follow it.
+UPDATE feed_follows
+SET feed_id = sqlc.arg(to_feed_id), updated_at = NOW()
+WHERE feed_follows.feed_id = sqlc.arg(from_feed_id)
+    AND feed_follows.user_id NOT IN (
+        SELECT existing.user_id
+        FROM feed_follows existing
+        WHERE existing.feed_id = sqlc.arg(to_feed_id)
+    );
+
+-- name: CreateFeedFollowIfMissing :execrows
+INSERT INTO feed_follows (id, user_id, feed_id, created_at, updated_at, folder)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (user_id, feed_id) DO NOTHING;
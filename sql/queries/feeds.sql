@@ -12,17 +12,112 @@ FROM feeds f INNER JOIN users u
 ON f.user_id = u.id;
 
 -- name: GetFeed :one
+-- Looks a feed up by its current URL or any URL it was moved from.
 SELECT *
 FROM feeds
-WHERE url = $1;
+WHERE feeds.url = sqlc.arg(url)
+    OR feeds.id = (
+        SELECT alias.feed_id
+        FROM feed_url_aliases alias
+        WHERE alias.url = sqlc.arg(url)
+    )
+LIMIT 1;
+
+-- name: GetNextFeedToFetch :one
+-- Claims the feed that has been due the longest, provisionally pushing its
+-- next_fetch_at out by claim_seconds until the fetch reschedules it. SKIP
+-- LOCKED lets concurrent workers claim different feeds.
+UPDATE feeds
+SET
+    last_fetched_at = NOW(),
+    next_fetch_at = NOW() + sqlc.arg(claim_seconds)::FLOAT * INTERVAL '1 second',
+    updated_at = NOW()
+WHERE id = (
+    SELECT due.id
+    FROM feeds due
+    WHERE due.disabled_at IS NULL
+        AND (due.next_fetch_at IS NULL OR due.next_fetch_at <= NOW())
+    ORDER BY due.next_fetch_at ASC NULLS FIRST
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: DeferFeed :exec
+-- Gives back a claimed feed that couldn't be fetched yet, to be due again
+-- after delay_seconds.
+UPDATE feeds
+SET next_fetch_at = NOW() + sqlc.arg(delay_seconds)::FLOAT * INTERVAL '1 second'
+WHERE id = sqlc.arg(id);
 
--- name: MarkFeedFetched :exec
-UPDATE feeds 
-SET last_fetched_at = NOW(), updated_at = NOW()
+-- name: ScheduleFeed :exec
+-- Records a successful fetch and when to fetch next.
+UPDATE feeds
+SET
+    consecutive_failures = 0,
+    last_error = NULL,
+    last_status = sqlc.arg(last_status),
+    last_success_at = NOW(),
+    ttl_seconds = sqlc.narg(ttl_seconds),
+    skip_hours = sqlc.arg(skip_hours),
+    skip_days = sqlc.arg(skip_days),
+    next_fetch_at = NOW() + sqlc.arg(delay_seconds)::FLOAT * INTERVAL '1 second',
+    updated_at = NOW()
+WHERE id = sqlc.arg(id);
+
+-- name: RecordFeedFailure :one
+-- Records a failed fetch, backing off by delay_seconds and disabling the feed
+-- once it reaches max_failures in a row.
+UPDATE feeds
+SET
+    consecutive_failures = consecutive_failures + 1,
+    last_error = sqlc.arg(last_error),
+    last_status = sqlc.narg(last_status),
+    next_fetch_at = NOW() + sqlc.arg(delay_seconds)::FLOAT * INTERVAL '1 second',
+    disabled_at = CASE
+        WHEN consecutive_failures + 1 >= sqlc.arg(max_failures)::INTEGER THEN NOW()
+        ELSE NULL
+    END,
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: EnableFeed :exec
+UPDATE feeds
+SET consecutive_failures = 0, disabled_at = NULL, next_fetch_at = NULL, updated_at = NOW()
 WHERE id = $1;
 
--- name: GetNextFeedToFetch :one
+-- name: GetFeedsHealth :many
 SELECT *
 FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
-LIMIT 1;
+ORDER BY disabled_at ASC NULLS FIRST, consecutive_failures DESC, name;
+
+-- name: SetFeedFetchInterval :exec
+UPDATE feeds
+SET fetch_interval_seconds = $2, next_fetch_at = NULL, updated_at = NOW()
+WHERE id = $1;
+
+-- name: SetFeedCacheValidators :exec
+UPDATE feeds
+SET etag = $2, last_modified = $3, updated_at = NOW()
+WHERE id = $1;
+
+-- name: UpdateFeedURL :exec
+UPDATE feeds
+SET url = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: DeleteFeed :exec
+DELETE FROM feeds
+WHERE id = $1;
+
+-- name: CreateFeedURLAlias :exec
+INSERT INTO feed_url_aliases (url, feed_id, created_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (url) DO UPDATE
+SET feed_id = EXCLUDED.feed_id, created_at = EXCLUDED.created_at;
+
+-- name: MoveFeedURLAliases :exec
+UPDATE feed_url_aliases
+SET feed_id = sqlc.arg(to_feed_id)
+WHERE feed_id = sqlc.arg(from_feed_id);
@@ -0,0 +1,16 @@
+-- name: MarkPostRead :exec
+INSERT INTO post_reads (user_id, post_id, read_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: MarkPostsRead :execrows
+-- Marks the user's unread posts as read, optionally only those of one feed
+-- or published before a given time.
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT feed_follows.user_id, posts.id, sqlc.arg(read_at)
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+    AND (sqlc.narg(feed_id)::UUID IS NULL OR posts.feed_id = sqlc.narg(feed_id))
+    AND (sqlc.narg(published_before)::TIMESTAMP IS NULL OR posts.published_at < sqlc.narg(published_before))
+ON CONFLICT (user_id, post_id) DO NOTHING;
@@ -1,21 +1,206 @@
 -- name: CreatePost :one
-INSERT INTO
-    posts (
-        id,
-        created_at,
-        updated_at,
-        title,
-        url,
-        description,
-        published_at,
-        feed_id
-    ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING *;
+WITH previous AS (
+    SELECT *
+    FROM posts
+    WHERE posts.feed_id = sqlc.arg(feed_id) AND posts.guid = sqlc.arg(guid)
+), upserted AS (
+    INSERT INTO
+        posts (
+            id,
+            created_at,
+            updated_at,
+            title,
+            url,
+            description,
+            published_at,
+            feed_id,
+            guid,
+            content_hash,
+            content,
+            author,
+            published_at_source
+        ) VALUES (
+            sqlc.arg(id),
+            sqlc.arg(created_at),
+            sqlc.arg(updated_at),
+            sqlc.arg(title),
+            sqlc.arg(url),
+            sqlc.arg(description),
+            sqlc.arg(published_at),
+            sqlc.arg(feed_id),
+            sqlc.arg(guid),
+            sqlc.arg(content_hash),
+            sqlc.arg(content),
+            sqlc.arg(author),
+            sqlc.arg(published_at_source)
+        )
+    ON CONFLICT (feed_id, guid) DO UPDATE
+    SET
+        updated_at = EXCLUDED.updated_at,
+        title = EXCLUDED.title,
+        url = EXCLUDED.url,
+        description = EXCLUDED.description,
+        -- an item without a usable date would otherwise be redated to the
+        -- fetch time on every edit
+        published_at = CASE
+            WHEN EXCLUDED.published_at_source = 'feed' THEN EXCLUDED.published_at
+            ELSE posts.published_at
+        END,
+        published_at_source = CASE
+            WHEN EXCLUDED.published_at_source = 'feed' THEN EXCLUDED.published_at_source
+            ELSE posts.published_at_source
+        END,
+        content_hash = EXCLUDED.content_hash,
+        content = EXCLUDED.content,
+        author = EXCLUDED.author
+    WHERE posts.content_hash <> EXCLUDED.content_hash
+    RETURNING *, (xmax = 0)::BOOLEAN AS inserted
+), revision AS (
+    -- keep the version being replaced, unless the update only adds the
+    -- content and author of a post stored before they were
+    INSERT INTO post_revisions (id, post_id, created_at, title, url, description, published_at, content, author)
+    SELECT
+        sqlc.arg(revision_id)::UUID,
+        previous.id,
+        sqlc.arg(updated_at),
+        previous.title,
+        previous.url,
+        previous.description,
+        previous.published_at,
+        previous.content,
+        previous.author
+    FROM previous
+    INNER JOIN upserted ON upserted.id = previous.id
+    WHERE previous.content_hash <> 'legacy:' || sqlc.arg(summary_hash)::TEXT
+)
+SELECT * FROM upserted;
 
--- name: GetPostsForUser :many
-SELECT posts.*
+-- name: AdoptLegacyPost :exec
+-- Gives a post stored before GUIDs were kept the GUID of the item with its
+-- url, so CreatePost updates it instead of inserting a copy.
+UPDATE posts
+SET guid = sqlc.arg(guid)
+WHERE posts.feed_id = sqlc.arg(feed_id)
+    AND posts.guid = 'legacy:' || sqlc.arg(url)::TEXT
+    AND NOT EXISTS (
+        SELECT 1
+        FROM posts existing
+        WHERE existing.feed_id = sqlc.arg(feed_id) AND existing.guid = sqlc.arg(guid)
+    );
+
+-- name: BrowsePostsForUser :many
+-- Pages through the posts of feeds the user follows, newest first by
+-- publication or fetch time. Pagination is by offset or by a cursor made of
+-- the last row's sort_key and id.
+SELECT
+    posts.*,
+    feeds.name AS feed_name,
+    sort.sort_key,
+    (
+        SELECT COUNT(*)
+        FROM post_revisions
+        WHERE post_revisions.post_id = posts.id
+    ) AS revisions,
+    EXISTS (
+        SELECT 1
+        FROM post_reads
+        WHERE post_reads.post_id = posts.id AND post_reads.user_id = feed_follows.user_id
+    ) AS is_read
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+INNER JOIN feeds ON feeds.id = posts.feed_id
+CROSS JOIN LATERAL (
+    SELECT CASE
+        WHEN sqlc.arg(sort_by)::TEXT = 'fetched' THEN posts.created_at
+        ELSE posts.published_at
+    END::TIMESTAMP AS sort_key
+) AS sort
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+    AND (
+        NOT sqlc.arg(unread_only)::BOOLEAN
+        OR NOT EXISTS (
+            SELECT 1
+            FROM post_reads
+            WHERE post_reads.post_id = posts.id AND post_reads.user_id = feed_follows.user_id
+        )
+    )
+    AND (sqlc.narg(feed_id)::UUID IS NULL OR posts.feed_id = sqlc.narg(feed_id))
+    AND (
+        sqlc.narg(category)::TEXT IS NULL
+        OR EXISTS (
+            SELECT 1
+            FROM post_categories
+            INNER JOIN categories ON categories.id = post_categories.category_id
+            WHERE post_categories.post_id = posts.id AND categories.name = LOWER(sqlc.narg(category))
+        )
+    )
+    AND (sqlc.narg(since)::TIMESTAMP IS NULL OR sort.sort_key >= sqlc.narg(since))
+    AND (sqlc.narg(until)::TIMESTAMP IS NULL OR sort.sort_key < sqlc.narg(until))
+    AND (
+        sqlc.narg(cursor_key)::TIMESTAMP IS NULL
+        OR (sort.sort_key, posts.id) < (sqlc.narg(cursor_key), sqlc.narg(cursor_id)::UUID)
+    )
+ORDER BY sort.sort_key DESC, posts.id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: GetPost :one
+SELECT *
+FROM posts
+WHERE id = $1;
+
+-- name: GetLatestPostRevision :one
+SELECT *
+FROM post_revisions
+WHERE post_id = $1
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: MovePosts :exec
+-- Moves posts to another feed, skipping items it already has.
+UPDATE posts
+SET feed_id = sqlc.arg(to_feed_id)
+WHERE posts.feed_id = sqlc.arg(from_feed_id)
+    AND posts.guid NOT IN (
+        SELECT existing.guid
+        FROM posts existing
+        WHERE existing.feed_id = sqlc.arg(to_feed_id)
+    );
+
+-- name: SearchPostsForUser :many
+-- Full-text search over the posts of feeds the user follows, best matches
+-- first. query accepts web search syntax: quoted phrases, OR and -exclusions.
+SELECT
+    posts.id,
+    posts.title,
+    posts.url,
+    posts.published_at,
+    feeds.name AS feed_name,
+    ts_rank(posts.search_vector, search.query)::FLOAT AS rank,
+    -- descriptions are HTML, highlight their text rather than the markup;
+    -- entities are left for the caller to decode
+    ts_headline(
+        'english',
+        regexp_replace(COALESCE(posts.description, posts.title), '<[^>]*>', ' ', 'g'),
+        search.query,
+        'StartSel=*, StopSel=*, MaxFragments=2, MaxWords=20, MinWords=5'
+    )::TEXT AS snippet
 FROM posts
 INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
-WHERE feed_follows.user_id = $1
-ORDER BY published_at DESC
-LIMIT $2;
+INNER JOIN feeds ON feeds.id = posts.feed_id
+CROSS JOIN websearch_to_tsquery('english', sqlc.arg(query)) AS search (query)
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+    AND posts.search_vector @@ search.query
+    AND (sqlc.narg(feed_id)::UUID IS NULL OR posts.feed_id = sqlc.narg(feed_id))
+    AND (
+        sqlc.narg(category)::TEXT IS NULL
+        OR EXISTS (
+            SELECT 1
+            FROM post_categories
+            INNER JOIN categories ON categories.id = post_categories.category_id
+            WHERE post_categories.post_id = posts.id AND categories.name = LOWER(sqlc.narg(category))
+        )
+    )
+    AND (sqlc.narg(published_since)::TIMESTAMP IS NULL OR posts.published_at >= sqlc.narg(published_since))
+ORDER BY rank DESC, posts.published_at DESC
+LIMIT sqlc.arg('limit');
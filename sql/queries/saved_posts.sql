@@ -0,0 +1,39 @@
+-- name: SavePost :execrows
+INSERT INTO
+    saved_posts (
+        id,
+        user_id,
+        post_id,
+        created_at,
+        title,
+        url,
+        description,
+        published_at,
+        feed_name
+    )
+SELECT
+    sqlc.arg(id),
+    sqlc.arg(user_id),
+    posts.id,
+    sqlc.arg(created_at),
+    posts.title,
+    posts.url,
+    posts.description,
+    posts.published_at,
+    feeds.name
+FROM posts
+INNER JOIN feeds ON feeds.id = posts.feed_id
+WHERE posts.id = sqlc.arg(post_id)
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: UnsavePost :execrows
+-- Accepts either the saved post's own id or the id of the original post.
+DELETE FROM saved_posts
+WHERE user_id = sqlc.arg(user_id)
+    AND (id = sqlc.arg(id) OR post_id = sqlc.arg(id));
+
+-- name: GetSavedPosts :many
+SELECT *
+FROM saved_posts
+WHERE user_id = $1
+ORDER BY created_at DESC;
@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE posts ADD guid VARCHAR;
+-- the feed's GUIDs weren't kept, so mark existing posts for the scraper to
+-- match by url and give them the real one, see AdoptLegacyPost
+UPDATE posts SET guid = 'legacy:' || url;
+ALTER TABLE posts ALTER COLUMN guid SET NOT NULL;
+ALTER TABLE posts DROP CONSTRAINT posts_url_key;
+ALTER TABLE posts ADD UNIQUE (feed_id, guid);
+
+-- +goose Down
+-- keep the oldest post for each url so it can be unique again
+DELETE FROM posts
+USING posts older
+WHERE posts.url = older.url
+    AND (posts.created_at, posts.id) > (older.created_at, older.id);
+ALTER TABLE posts DROP guid;
+ALTER TABLE posts ADD UNIQUE (url);
@@ -0,0 +1,23 @@
+-- +goose Up
+ALTER TABLE posts ADD content_hash VARCHAR;
+UPDATE posts
+SET content_hash = encode(
+    sha256(convert_to(url || E'\n' || title || E'\n' || COALESCE(description, ''), 'UTF8')),
+    'hex'
+);
+ALTER TABLE posts ALTER COLUMN content_hash SET NOT NULL;
+
+CREATE TABLE
+    post_revisions (
+        id UUID PRIMARY KEY,
+        post_id UUID NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+        created_at TIMESTAMP NOT NULL,
+        title VARCHAR NOT NULL,
+        url VARCHAR NOT NULL,
+        description VARCHAR,
+        published_at TIMESTAMP NOT NULL
+    );
+
+-- +goose Down
+DROP TABLE post_revisions;
+ALTER TABLE posts DROP content_hash;
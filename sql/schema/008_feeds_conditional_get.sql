@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE feeds ADD etag VARCHAR;
+ALTER TABLE feeds ADD last_modified VARCHAR;
+
+-- +goose Down
+ALTER TABLE feeds DROP last_modified;
+ALTER TABLE feeds DROP etag;
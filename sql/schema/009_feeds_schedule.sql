@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE feeds ADD next_fetch_at TIMESTAMP;
+ALTER TABLE feeds ADD fetch_interval_seconds INTEGER;
+ALTER TABLE feeds ADD ttl_seconds INTEGER;
+ALTER TABLE feeds ADD skip_hours INTEGER[] NOT NULL DEFAULT '{}';
+ALTER TABLE feeds ADD skip_days INTEGER[] NOT NULL DEFAULT '{}';
+CREATE INDEX feeds_next_fetch_at_idx ON feeds (next_fetch_at NULLS FIRST);
+
+-- +goose Down
+DROP INDEX feeds_next_fetch_at_idx;
+ALTER TABLE feeds DROP skip_days;
+ALTER TABLE feeds DROP skip_hours;
+ALTER TABLE feeds DROP ttl_seconds;
+ALTER TABLE feeds DROP fetch_interval_seconds;
+ALTER TABLE feeds DROP next_fetch_at;
@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE feeds ADD consecutive_failures INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE feeds ADD last_error VARCHAR;
+ALTER TABLE feeds ADD last_status INTEGER;
+ALTER TABLE feeds ADD last_success_at TIMESTAMP;
+ALTER TABLE feeds ADD disabled_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE feeds DROP disabled_at;
+ALTER TABLE feeds DROP last_success_at;
+ALTER TABLE feeds DROP last_status;
+ALTER TABLE feeds DROP last_error;
+ALTER TABLE feeds DROP consecutive_failures;
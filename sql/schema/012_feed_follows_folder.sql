@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE feed_follows ADD folder VARCHAR;
+
+-- +goose Down
+ALTER TABLE feed_follows DROP folder;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- Saved posts keep a copy of the post so they survive the post being pruned
+-- or its feed being unfollowed or deleted.
+CREATE TABLE
+    saved_posts (
+        id UUID PRIMARY KEY,
+        user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        post_id UUID REFERENCES posts (id) ON DELETE SET NULL,
+        created_at TIMESTAMP NOT NULL,
+        title VARCHAR NOT NULL,
+        url VARCHAR NOT NULL,
+        description VARCHAR,
+        published_at TIMESTAMP NOT NULL,
+        feed_name VARCHAR NOT NULL,
+        UNIQUE (user_id, post_id)
+    );
+
+-- +goose Down
+DROP TABLE saved_posts;
@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE posts ADD content VARCHAR;
+ALTER TABLE posts ADD author VARCHAR;
+ALTER TABLE post_revisions ADD content VARCHAR;
+ALTER TABLE post_revisions ADD author VARCHAR;
+-- the content hash now covers content and author, which existing posts
+-- lack; marking their hashes lets CreatePost fill them in on the next fetch
+-- without recording it as an edit
+UPDATE posts SET content_hash = 'legacy:' || content_hash;
+
+-- +goose Down
+UPDATE posts SET content_hash = substring(content_hash FROM 8) WHERE content_hash LIKE 'legacy:%';
+ALTER TABLE post_revisions DROP author;
+ALTER TABLE post_revisions DROP content;
+ALTER TABLE posts DROP author;
+ALTER TABLE posts DROP content;
@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE
+    categories (
+        id UUID PRIMARY KEY,
+        name VARCHAR NOT NULL UNIQUE
+    );
+
+CREATE TABLE
+    post_categories (
+        post_id UUID NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+        category_id UUID NOT NULL REFERENCES categories (id) ON DELETE CASCADE,
+        PRIMARY KEY (post_id, category_id)
+    );
+
+CREATE INDEX post_categories_category_id_idx ON post_categories (category_id);
+
+-- +goose Down
+DROP TABLE post_categories;
+DROP TABLE categories;
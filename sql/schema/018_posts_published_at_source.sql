@@ -0,0 +1,7 @@
+-- +goose Up
+-- feed, missing or invalid: whether published_at came from the item or is
+-- the fetch time used in place of a missing or unparseable date
+ALTER TABLE posts ADD published_at_source VARCHAR NOT NULL DEFAULT 'feed';
+
+-- +goose Down
+ALTER TABLE posts DROP published_at_source;